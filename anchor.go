@@ -0,0 +1,150 @@
+package sidetree
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	"github.com/mr-tron/base58"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// AnchorString is a parsed anchor string, "<operations>.<core index URI>":
+// the number of operations a writer declares (and pays for) and the CAS URI
+// of the batch's core index file.
+//
+// ion-sdk-go's operations.AnchorString is parsed leniently: a count that is
+// not a number reads as 0, a negative count parses through, and anything
+// after a second dot is ignored. ParseAnchorString accepts only what the
+// reference implementation writes.
+type AnchorString struct {
+	Operations       int
+	CoreIndexFileURI string
+}
+
+// ParseAnchorString parses anchor. The count must be a positive decimal
+// without a sign or leading zeros, it must be separated from the URI by the
+// only dot in anchor, and the URI must be a CID string. An invalid count wraps
+// ErrInvalidOperationCount; every error wraps ErrInvalidAnchorString.
+func ParseAnchorString(anchor string) (AnchorString, error) {
+	count, uri, ok := strings.Cut(anchor, ".")
+	if !ok {
+		return AnchorString{}, fmt.Errorf("%w: %q has no dot", ErrInvalidAnchorString, anchor)
+	}
+	if strings.Contains(uri, ".") {
+		return AnchorString{}, fmt.Errorf("%w: %q has more than one dot", ErrInvalidAnchorString, anchor)
+	}
+
+	ops, err := parseOperationCount(count)
+	if err != nil {
+		return AnchorString{}, fmt.Errorf("%w: %w: %q: %w", ErrInvalidAnchorString, ErrInvalidOperationCount, count, err)
+	}
+	if err := checkCIDSyntax(uri); err != nil {
+		return AnchorString{}, fmt.Errorf("%w: core index URI %q: %w", ErrInvalidAnchorString, uri, err)
+	}
+
+	return AnchorString{Operations: ops, CoreIndexFileURI: uri}, nil
+}
+
+// String returns the anchor string a, unchecked.
+func (a AnchorString) String() string {
+	return strconv.Itoa(a.Operations) + "." + a.CoreIndexFileURI
+}
+
+// Format returns a as the operations.AnchorString the rest of the toolkit
+// passes around, after the checks ParseAnchorString makes.
+func (a AnchorString) Format() (operations.AnchorString, error) {
+	s := a.String()
+	if _, err := ParseAnchorString(s); err != nil {
+		return "", err
+	}
+	return operations.AnchorString(s), nil
+}
+
+// parseOperationCount parses a positive decimal count with no sign or leading
+// zeros.
+func parseOperationCount(count string) (int, error) {
+	if count == "" {
+		return 0, fmt.Errorf("empty")
+	}
+	if count[0] == '0' {
+		return 0, fmt.Errorf("not positive or has a leading zero")
+	}
+	for _, c := range count {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("not a decimal number")
+		}
+	}
+	ops, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, err
+	}
+	return ops, nil
+}
+
+// checkCIDSyntax checks that uri is a CID string: a base58btc CIDv0 ("Qm...",
+// 46 characters) or a CIDv1 in one of the multibase encodings CAS URIs use,
+// base32 ("b"), base58btc ("z"), base16 ("f") or base64url ("u"), whose
+// version is 1 and whose codec is followed by a complete multihash and
+// nothing else. What the CID names is up to the CAS.
+func checkCIDSyntax(uri string) error {
+	if uri == "" {
+		return fmt.Errorf("empty")
+	}
+	if uri[0] == 'Q' {
+		if len(uri) != 46 {
+			return fmt.Errorf("CIDv0 is %d characters, not 46", len(uri))
+		}
+		hash, err := mh.FromB58String(uri)
+		if err != nil {
+			return fmt.Errorf("invalid CIDv0: %w", err)
+		}
+		if len(hash) != 34 || hash[0] != mh.SHA2_256 {
+			return fmt.Errorf("CIDv0 multihash is not SHA-256")
+		}
+		return nil
+	}
+
+	var cid []byte
+	var err error
+	switch uri[0] {
+	case 'b':
+		if uri != strings.ToLower(uri) {
+			return fmt.Errorf("base32 CID is not lower case")
+		}
+		cid, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(uri[1:]))
+	case 'z':
+		cid, err = base58.Decode(uri[1:])
+	case 'f':
+		if uri != strings.ToLower(uri) {
+			return fmt.Errorf("base16 CID is not lower case")
+		}
+		cid, err = hex.DecodeString(uri[1:])
+	case 'u':
+		cid, err = base64.RawURLEncoding.DecodeString(uri[1:])
+	default:
+		return fmt.Errorf("unsupported multibase prefix %q", uri[0])
+	}
+	if err != nil {
+		return fmt.Errorf("invalid multibase %q encoding: %w", uri[0], err)
+	}
+
+	version, n := binary.Uvarint(cid)
+	if n <= 0 || version != 1 {
+		return fmt.Errorf("not a CIDv1")
+	}
+	_, m := binary.Uvarint(cid[n:])
+	if m <= 0 {
+		return fmt.Errorf("invalid CID codec")
+	}
+	// Cast rejects a truncated multihash and trailing bytes alike.
+	if _, err := mh.Cast(cid[n+m:]); err != nil {
+		return fmt.Errorf("invalid CID multihash: %w", err)
+	}
+	return nil
+}
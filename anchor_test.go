@@ -0,0 +1,90 @@
+package sidetree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAnchorString(t *testing.T) {
+	tests := map[string]struct {
+		anchor  string
+		want    AnchorString
+		wantErr error
+	}{
+		"CIDv0": {
+			anchor: "1.QmWTbhuL5QBLgEUCgtR7WxNEbuCw3FkjbBMDJ9y5bQuwKz",
+			want:   AnchorString{Operations: 1, CoreIndexFileURI: "QmWTbhuL5QBLgEUCgtR7WxNEbuCw3FkjbBMDJ9y5bQuwKz"},
+		},
+		"CIDv1": {
+			anchor: "10000.bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy",
+			want:   AnchorString{Operations: 10000, CoreIndexFileURI: "bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy"},
+		},
+		"CIDv1 base58btc": {
+			anchor: "1.zb2rho2WzHN83LhxVd3Mvk7sbjfmmE8kKbLhyghthKSpcyikN",
+			want:   AnchorString{Operations: 1, CoreIndexFileURI: "zb2rho2WzHN83LhxVd3Mvk7sbjfmmE8kKbLhyghthKSpcyikN"},
+		},
+		"CIDv1 base16": {
+			anchor: "1.f01551220f36d5dec92c993324adedae84cfd798b88f31b748036275355d1fbe7817b8627",
+			want:   AnchorString{Operations: 1, CoreIndexFileURI: "f01551220f36d5dec92c993324adedae84cfd798b88f31b748036275355d1fbe7817b8627"},
+		},
+		"CIDv1 base64url": {
+			anchor: "1.uAVUSIPNtXeySyZMySt7a6Ez9eYuI8xt0gDYnU1XR--eBe4Yn",
+			want:   AnchorString{Operations: 1, CoreIndexFileURI: "uAVUSIPNtXeySyZMySt7a6Ez9eYuI8xt0gDYnU1XR--eBe4Yn"},
+		},
+		"zero":                    {anchor: "0." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"negative":                {anchor: "-5." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"plus sign":               {anchor: "+5." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"leading zero":            {anchor: "05." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"not a number":            {anchor: "abc." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"empty count":             {anchor: "." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"overflow":                {anchor: "99999999999999999999." + coreIndexCID, wantErr: ErrInvalidOperationCount},
+		"no dot":                  {anchor: "1", wantErr: ErrInvalidAnchorString},
+		"two dots":                {anchor: "1." + coreIndexCID + "." + coreIndexCID, wantErr: ErrInvalidAnchorString},
+		"empty URI":               {anchor: "1.", wantErr: ErrInvalidAnchorString},
+		"URI with path":           {anchor: "1." + coreIndexCID + "/../x", wantErr: ErrInvalidAnchorString},
+		"not a CID":               {anchor: "1.core-index", wantErr: ErrInvalidAnchorString},
+		"unsupported base":        {anchor: "1.mAVUSIPNtXeySyZMySt7a6Ez9eYuI8xt0gDYnU1XR", wantErr: ErrInvalidAnchorString},
+		"short CIDv0":             {anchor: "1.QmeitWE9tC5h1JmVNpyqBhMD1VWe2qGboCtdb2Rs4M1YY", wantErr: ErrInvalidAnchorString},
+		"CIDv0 not base58":        {anchor: "1.Qm00000000000000000000000000000000000000000000", wantErr: ErrInvalidAnchorString},
+		"CIDv1 not base32":        {anchor: "1.bafkrei0", wantErr: ErrInvalidAnchorString},
+		"CIDv1 upper case base32": {anchor: "1.bafkreihtnvo6zewjsmzevxw25bgp26mlrdzrw5eagytvgvor7ptyc64GE4", wantErr: ErrInvalidAnchorString},
+		"CIDv1 upper case hex":    {anchor: "1.f01551220F36D5DEC92C993324ADEDAE84CFD798B88F31B748036275355D1FBE7817B8627", wantErr: ErrInvalidAnchorString},
+		"CID version 2":           {anchor: "1.bajkreihtnvo6zewjsmzevxw25bgp26mlrdzrw5eagytvgvor7ptyc64ge4", wantErr: ErrInvalidAnchorString},
+		"truncated multihash":     {anchor: "1.bafkreihtnvo6zewjsmzevxw25bgp26mlrdzrw5eagytvgvor7ptyc64", wantErr: ErrInvalidAnchorString},
+		"trailing bytes":          {anchor: "1.bafkreihtnvo6zewjsmzevxw25bgp26mlrdzrw5eagytvgvor7ptyc64ge4aa", wantErr: ErrInvalidAnchorString},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseAnchorString(test.anchor)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidAnchorString) {
+					t.Errorf("expected every error to wrap %v, got %v", ErrInvalidAnchorString, err)
+				}
+				return
+			}
+			if got != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+			if got.String() != test.anchor {
+				t.Errorf("expected %q to format back unchanged, got %q", test.anchor, got.String())
+			}
+		})
+	}
+}
+
+func TestAnchorStringFormat(t *testing.T) {
+	got, err := AnchorString{Operations: 3, CoreIndexFileURI: coreIndexCID}.Format()
+	if err != nil || got != "3."+coreIndexCID {
+		t.Errorf("expected 3.%s, got %q, %v", coreIndexCID, got, err)
+	}
+	if _, err := (AnchorString{Operations: 0, CoreIndexFileURI: coreIndexCID}).Format(); !errors.Is(err, ErrInvalidOperationCount) {
+		t.Errorf("expected %v, got %v", ErrInvalidOperationCount, err)
+	}
+	if _, err := (AnchorString{Operations: 1, CoreIndexFileURI: "a.b"}).Format(); !errors.Is(err, ErrInvalidAnchorString) {
+		t.Errorf("expected %v, got %v", ErrInvalidAnchorString, err)
+	}
+}
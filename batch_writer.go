@@ -0,0 +1,272 @@
+package sidetree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// DefaultWriteInterval is how often a BatchWriter writes a batch unless
+// configured WithWriteInterval, the reference batching interval.
+const DefaultWriteInterval = 10 * time.Minute
+
+// maxFeeBackoff caps how many write intervals Run waits after a Write whose
+// fee is rejected.
+const maxFeeBackoff = 16
+
+var (
+	ErrInvalidQueue    = fmt.Errorf("invalid operation queue")
+	ErrInvalidAnchorer = fmt.Errorf("invalid anchorer")
+	ErrInvalidInterval = fmt.Errorf("invalid write interval")
+
+	// ErrFeeRejected: the configured per-operation fee or value-lock callback
+	// rejects the batch a BatchWriter would anchor, so a reader configured with
+	// the same callbacks would reject it too. The batch is not written and its
+	// operations stay queued: the callbacks are given the anchor point, and
+	// may accept the batch later.
+	ErrFeeRejected = fmt.Errorf("batch fee is not accepted")
+)
+
+// Anchorer writes anchor strings to the ledger readers discover them on.
+type Anchorer interface {
+	// AnchorPoint returns the ledger point the next anchor is expected at,
+	// which the fee callbacks are given as anchorPoint.
+	AnchorPoint() (string, error)
+	// Anchor writes anchor to the ledger, paying fee.
+	Anchor(anchor operations.AnchorString, fee int) error
+}
+
+// WithWriteInterval sets how often a BatchWriter's Run writes a batch.
+func WithWriteInterval(interval time.Duration) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *BatchWriter:
+			t.interval = interval
+		}
+	}
+}
+
+// WithWriterValueLock lets a BatchWriter write batches of up to maxOperations
+// operations under the value lock writerLockID (see WithPackValueLock).
+func WithWriterValueLock(writerLockID string, maxOperations int) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *BatchWriter:
+			t.packOpts = []PackOption{WithPackValueLock(writerLockID, maxOperations)}
+		}
+	}
+}
+
+// BatchWriter anchors the operations of an OperationQueue: each Write packs
+// the pending requests (see PackBatches), writes the first batch to the CAS
+// and anchors it, and removes its operations from the queue. Requests that can
+// never be anchored are removed too; the rest wait for the next Write. While
+// the fee of the first batch is rejected, Run backs off.
+//
+// If the queue fails to remove the operations of an anchored batch, the writer
+// keeps their ids and the next Write removes them before it packs anything,
+// so they are not anchored twice. The ids are kept in memory only.
+//
+// It is configured with SideTreeOptions: WithCAS (required), WithWriteInterval,
+// WithWriterValueLock, WithFeeFunctions and WithLogger. The fee callbacks are
+// those a reader is configured with: the BaseFeeAlgorithm sets the fee the
+// Anchorer pays, and a batch the PerOperationFee or ValueLocking callback
+// rejects is not anchored.
+type BatchWriter struct {
+	queue    OperationQueue
+	anchorer Anchorer
+	cas      CAS
+	interval time.Duration
+	packOpts []PackOption
+
+	baseFeeFn   BaseFeeAlgorithm
+	perOpFeeFn  PerOperationFee
+	valueLockFn ValueLocking
+
+	log *slog.Logger
+
+	// anchored holds the queue ids of anchored operations the queue failed
+	// to remove.
+	anchored []uint64
+}
+
+func NewBatchWriter(queue OperationQueue, anchorer Anchorer, options ...SideTreeOption) (*BatchWriter, error) {
+	w := &BatchWriter{queue: queue, anchorer: anchorer, interval: DefaultWriteInterval}
+	for _, option := range options {
+		option(w)
+	}
+
+	if w.queue == nil {
+		return nil, ErrInvalidQueue
+	}
+	if w.anchorer == nil {
+		return nil, ErrInvalidAnchorer
+	}
+	if w.cas == nil {
+		return nil, ErrInvalidCAS
+	}
+	if w.interval <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInterval, w.interval)
+	}
+
+	return w, nil
+}
+
+func (w *BatchWriter) logger() *slog.Logger {
+	if w.log == nil {
+		return discardLogger
+	}
+	return w.log
+}
+
+// Run calls Write every write interval until ctx is done, and returns ctx's
+// error. A failed Write is logged and retried at the next interval. After each
+// Write in a row whose fee is rejected (ErrFeeRejected) the wait doubles, up
+// to maxFeeBackoff write intervals, and it is reset once a Write succeeds.
+func (w *BatchWriter) Run(ctx context.Context) error {
+	wait := w.interval
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			anchor, err := w.Write()
+			wait = w.nextWait(wait, err)
+			timer.Reset(wait)
+			switch {
+			case errors.Is(err, ErrFeeRejected):
+				w.logger().Warn("batch fee rejected, operations stay queued", "error", err, "retryIn", wait)
+			case err != nil:
+				w.logger().Error("batch write failed", "error", err)
+			case anchor != "":
+				w.logger().Info("batch anchored", "anchor", string(anchor))
+			}
+		}
+	}
+}
+
+// nextWait returns how long Run waits after a Write that returned err, having
+// waited wait before it.
+func (w *BatchWriter) nextWait(wait time.Duration, err error) time.Duration {
+	if !errors.Is(err, ErrFeeRejected) {
+		return w.interval
+	}
+	return min(2*wait, maxFeeBackoff*w.interval)
+}
+
+// Write anchors one batch of the pending operations and returns its anchor
+// string, or "" if no operation is pending.
+func (w *BatchWriter) Write() (operations.AnchorString, error) {
+	if len(w.anchored) > 0 {
+		if err := w.queue.Remove(w.anchored...); err != nil {
+			return "", fmt.Errorf("failed to remove anchored operations from the queue: %w", err)
+		}
+		w.anchored = nil
+	}
+
+	pending, err := w.queue.Pending()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the operation queue: %w", err)
+	}
+
+	// ids holds the queue id of each operation in ops; drop the ids of the
+	// requests no batch will ever carry.
+	var ops []interface{}
+	var ids, drop []uint64
+	for _, p := range pending {
+		op, err := ParseOperationRequest(p.Request)
+		if err != nil {
+			w.logger().Warn("dropping invalid queued operation", "id", p.ID, "error", err)
+			drop = append(drop, p.ID)
+			continue
+		}
+		ops = append(ops, op)
+		ids = append(ids, p.ID)
+	}
+	if len(ops) == 0 {
+		return "", w.queue.Remove(drop...)
+	}
+
+	packing, err := PackBatches(ops, w.packOpts...)
+	if err != nil {
+		return "", err
+	}
+	for i, reason := range packing.Rejected {
+		w.logger().Warn("dropping queued operation no batch can carry", "id", ids[i], "error", reason)
+		drop = append(drop, ids[i])
+	}
+	if len(packing.Batches) == 0 {
+		return "", w.queue.Remove(drop...)
+	}
+
+	// A later batch is not tried when the first one's fee is rejected: it may
+	// carry operations that must be anchored after the first batch's.
+	batch := packing.Batches[0]
+	fee, err := w.fee(batch)
+	if err != nil {
+		return "", w.removeAfter(err, drop)
+	}
+	anchor, err := batch.Write(w.cas)
+	if err != nil {
+		return "", w.removeAfter(fmt.Errorf("failed to write batch: %w", err), drop)
+	}
+	if err := w.anchorer.Anchor(anchor, fee); err != nil {
+		return "", w.removeAfter(fmt.Errorf("failed to anchor %s: %w", anchor, err), drop)
+	}
+
+	queued := map[interface{}]uint64{}
+	for i, op := range ops {
+		queued[op] = ids[i]
+	}
+	var anchored []uint64
+	for _, op := range batch.ops() {
+		anchored = append(anchored, queued[op])
+	}
+	if err := w.queue.Remove(append(drop, anchored...)...); err != nil {
+		w.anchored = anchored
+		return anchor, fmt.Errorf("anchored %s but failed to remove its operations from the queue: %w", anchor, err)
+	}
+	return anchor, nil
+}
+
+// removeAfter removes the dropped requests after a failed Write, which
+// returns err.
+func (w *BatchWriter) removeAfter(err error, drop []uint64) error {
+	if rmErr := w.queue.Remove(drop...); rmErr != nil {
+		w.logger().Error("failed to remove dropped operations from the queue", "error", rmErr)
+	}
+	return err
+}
+
+// fee returns the fee to anchor batch with, after the checks a reader with the
+// same fee callbacks makes.
+func (w *BatchWriter) fee(batch *Batch) (int, error) {
+	if w.baseFeeFn == nil && w.perOpFeeFn == nil && w.valueLockFn == nil {
+		return 0, nil
+	}
+
+	anchorPoint, err := w.anchorer.AnchorPoint()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the anchor point: %w", err)
+	}
+	opCount := batch.OperationCount()
+
+	var fee int
+	if w.baseFeeFn != nil {
+		fee = w.baseFeeFn(opCount, anchorPoint)
+	}
+	if w.perOpFeeFn != nil && !w.perOpFeeFn(fee, opCount, anchorPoint) {
+		return 0, fmt.Errorf("%w: per op fee is not valid for %d operations", ErrFeeRejected, opCount)
+	}
+	if w.valueLockFn != nil && !w.valueLockFn(batch.writerLockID, fee, opCount, anchorPoint) {
+		return 0, fmt.Errorf("%w: value lock %q is not valid for %d operations", ErrFeeRejected, batch.writerLockID, opCount)
+	}
+	return fee, nil
+}
@@ -0,0 +1,249 @@
+package sidetree
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// testAnchorer records the anchors written to it.
+type testAnchorer struct {
+	anchors []operations.AnchorString
+	fees    []int
+	written chan struct{}
+}
+
+func (a *testAnchorer) AnchorPoint() (string, error) { return "100:block:0:tx", nil }
+
+func (a *testAnchorer) Anchor(anchor operations.AnchorString, fee int) error {
+	a.anchors = append(a.anchors, anchor)
+	a.fees = append(a.fees, fee)
+	if a.written != nil {
+		a.written <- struct{}{}
+	}
+	return nil
+}
+
+// newTestQueue returns a FileQueue holding ops, marshaled as requests.
+func newTestQueue(t *testing.T, ops ...interface{}) *FileQueue {
+	t.Helper()
+	q, err := OpenFileQueue(filepath.Join(t.TempDir(), "queue"))
+	if err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	for _, op := range ops {
+		request, ok := op.([]byte)
+		if !ok {
+			if request, err = json.Marshal(op); err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+		}
+		if _, err := q.Enqueue(request); err != nil {
+			t.Fatalf("failed to enqueue: %v", err)
+		}
+	}
+	return q
+}
+
+func TestBatchWriterWrite(t *testing.T) {
+	a := newTestDID(t, "writer-a")
+	b := newTestDID(t, "writer-b")
+	cas := NewTestCAS()
+	// a is created in the first batch and updated in the second.
+	queue := newTestQueue(t,
+		a.create,
+		a.update(t, a.updateKey, "updated", a.nextUpdateKey),
+		b.create,
+		[]byte(`{"type":"deactivate","didSuffix":"not-a-suffix","revealValue":"r","signedData":"x"}`),
+	)
+	anchorer := &testAnchorer{}
+	w, err := NewBatchWriter(queue, anchorer, WithCAS(cas),
+		WithFeeFunctions(BaseFeeAlgorithm(func(opCount int, anchorPoint string) int { return 10 * opCount })))
+	if err != nil {
+		t.Fatalf("expected no error creating the writer, got %v", err)
+	}
+
+	state := NewState("test")
+	for i, wantOps := range []int{2, 1} {
+		anchor, err := w.Write()
+		if err != nil {
+			t.Fatalf("write %d: expected no error, got %v", i, err)
+		}
+		p, err := Processor(operations.Anchor{Anchor: anchor, Sequence: NewTransactionNumber(uint32(i+1), 0).Sequence("block", "tx")}, WithCAS(cas), WithPrefix("test"), WithSignatureVerification(true))
+		if err != nil {
+			t.Fatalf("write %d: expected no error creating processor, got %v", i, err)
+		}
+		processed := p.Process()
+		if processed.Error != nil || len(processed.Results) != wantOps {
+			t.Fatalf("write %d: expected %d valid operations, got %v, %v", i, wantOps, processed.Results, processed.Error)
+		}
+		applyAnchors(t, state, processed)
+		if anchorer.fees[i] != 10*wantOps {
+			t.Errorf("write %d: expected fee %d, got %d", i, 10*wantOps, anchorer.fees[i])
+		}
+	}
+
+	if pending, _ := queue.Pending(); len(pending) != 0 {
+		t.Errorf("expected an empty queue, got %v", pending)
+	}
+	if anchor, err := w.Write(); anchor != "" || err != nil {
+		t.Errorf("expected nothing to write, got %q, %v", anchor, err)
+	}
+	doc, err := state.Resolve(a.suffix)
+	if err != nil {
+		t.Fatalf("expected %s to resolve, got %v", a.suffix, err)
+	}
+	if got := serviceIDs(doc); len(got) != 2 {
+		t.Errorf("expected the update to apply, got services %v", got)
+	}
+}
+
+func TestBatchWriterFeeRejected(t *testing.T) {
+	d := newTestDID(t, "writer-fee")
+	queue := newTestQueue(t, d.create)
+	anchorer := &testAnchorer{}
+	accept := false
+	w, err := NewBatchWriter(queue, anchorer, WithCAS(NewTestCAS()),
+		WithFeeFunctions(PerOperationFee(func(baseFee, opCount int, anchorPoint string) bool { return accept })))
+	if err != nil {
+		t.Fatalf("expected no error creating the writer, got %v", err)
+	}
+
+	if _, err := w.Write(); !errors.Is(err, ErrFeeRejected) {
+		t.Errorf("expected %v, got %v", ErrFeeRejected, err)
+	}
+	if len(anchorer.anchors) != 0 {
+		t.Errorf("expected nothing anchored, got %v", anchorer.anchors)
+	}
+	if pending, _ := queue.Pending(); len(pending) != 1 {
+		t.Errorf("expected the operation to stay queued, got %v", pending)
+	}
+
+	// The callbacks accept the fee at a later anchor point.
+	accept = true
+	if anchor, err := w.Write(); anchor == "" || err != nil {
+		t.Errorf("expected the queued operation to anchor, got %q, %v", anchor, err)
+	}
+	if pending, _ := queue.Pending(); len(pending) != 0 {
+		t.Errorf("expected an empty queue, got %v", pending)
+	}
+}
+
+func TestBatchWriterBackoff(t *testing.T) {
+	w := &BatchWriter{interval: time.Minute}
+	rejected := fmt.Errorf("%w: per op fee is not valid", ErrFeeRejected)
+
+	wait := w.interval
+	var got []time.Duration
+	for range 6 {
+		wait = w.nextWait(wait, rejected)
+		got = append(got, wait)
+	}
+	want := []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 16 * time.Minute, 16 * time.Minute}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected waits %v, got %v", want, got)
+	}
+	if wait := w.nextWait(wait, nil); wait != w.interval {
+		t.Errorf("expected a successful write to reset the wait to %s, got %s", w.interval, wait)
+	}
+	if wait := w.nextWait(wait, errors.New("anchorer offline")); wait != w.interval {
+		t.Errorf("expected other errors not to back off, got %s", wait)
+	}
+}
+
+// flakyQueue is an OperationQueue whose Remove fails the first failRemoves
+// times.
+type flakyQueue struct {
+	*FileQueue
+	failRemoves int
+}
+
+func (q *flakyQueue) Remove(ids ...uint64) error {
+	if q.failRemoves > 0 {
+		q.failRemoves--
+		return errors.New("disk full")
+	}
+	return q.FileQueue.Remove(ids...)
+}
+
+func TestBatchWriterRemoveFailure(t *testing.T) {
+	d := newTestDID(t, "writer-remove")
+	queue := &flakyQueue{FileQueue: newTestQueue(t, d.create), failRemoves: 2}
+	anchorer := &testAnchorer{}
+	w, err := NewBatchWriter(queue, anchorer, WithCAS(NewTestCAS()))
+	if err != nil {
+		t.Fatalf("expected no error creating the writer, got %v", err)
+	}
+
+	anchor, err := w.Write()
+	if anchor == "" || err == nil {
+		t.Fatalf("expected the batch to anchor and its removal to fail, got %q, %v", anchor, err)
+	}
+	// The removal fails again: the operation stays queued but must not be
+	// anchored a second time.
+	if _, err := w.Write(); err == nil {
+		t.Error("expected the retried removal to fail")
+	}
+	if anchor, err := w.Write(); anchor != "" || err != nil {
+		t.Errorf("expected nothing to write, got %q, %v", anchor, err)
+	}
+	if len(anchorer.anchors) != 1 {
+		t.Errorf("expected one anchor, got %v", anchorer.anchors)
+	}
+	if pending, _ := queue.Pending(); len(pending) != 0 {
+		t.Errorf("expected an empty queue, got %v", pending)
+	}
+}
+
+func TestBatchWriterRun(t *testing.T) {
+	d := newTestDID(t, "writer-run")
+	anchorer := &testAnchorer{written: make(chan struct{}, 1)}
+	w, err := NewBatchWriter(newTestQueue(t, d.create), anchorer, WithCAS(NewTestCAS()), WithWriteInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("expected no error creating the writer, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case <-anchorer.written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Run to anchor the queued operation")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestNewBatchWriterRejects(t *testing.T) {
+	queue := newTestQueue(t)
+	tests := map[string]struct {
+		queue    OperationQueue
+		anchorer Anchorer
+		opts     []SideTreeOption
+		wantErr  error
+	}{
+		"no queue":    {anchorer: &testAnchorer{}, opts: []SideTreeOption{WithCAS(NewTestCAS())}, wantErr: ErrInvalidQueue},
+		"no anchorer": {queue: queue, opts: []SideTreeOption{WithCAS(NewTestCAS())}, wantErr: ErrInvalidAnchorer},
+		"no CAS":      {queue: queue, anchorer: &testAnchorer{}, wantErr: ErrInvalidCAS},
+		"no interval": {queue: queue, anchorer: &testAnchorer{}, opts: []SideTreeOption{WithCAS(NewTestCAS()), WithWriteInterval(0)}, wantErr: ErrInvalidInterval},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewBatchWriter(test.queue, test.anchorer, test.opts...); !errors.Is(err, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
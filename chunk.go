@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/did"
 	"github.com/13x-tech/ion-sdk-go/pkg/operations"
@@ -39,25 +40,48 @@ func WithOperations(
 	}
 }
 
+// WithChunkDecodeMode selects how the chunk file JSON is decoded. The default
+// is DecodeStrict.
+func WithChunkDecodeMode(mode DecodeMode) ChunkOption {
+	return func(c *ChunkFile) {
+		c.decodeMode = mode
+	}
+}
+
+// WithChunkLogger sets the logger chunk processing reports to. By default
+// nothing is logged.
+func WithChunkLogger(logger *slog.Logger) ChunkOption {
+	return func(c *ChunkFile) {
+		c.log = logger
+	}
+}
+
 func NewChunkFile(data []byte, opts ...ChunkOption) (*ChunkFile, error) {
 	var c ChunkFile
-	if err := json.Unmarshal(data, &c); err != nil {
-		return nil, fmt.Errorf("unable to unmarshal: %w", err)
+	for _, opt := range opts {
+		opt(&c)
 	}
 
 	// Capture the raw on-wire bytes of each delta so the per-delta size cap (#32)
 	// is measured on what was actually anchored, not a re-marshaled did.Delta
-	// (which would silently drop unknown fields and undercount the size).
+	// (which would silently drop unknown fields and undercount the size). Strict
+	// decoding applies to the file envelope; each delta is then decoded on its
+	// own, so unknown properties inside a delta do not reject the batch.
 	var raw struct {
 		Deltas []json.RawMessage `json:"deltas"`
 	}
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("unable to unmarshal raw deltas: %w", err)
+	if err := decodeJSON(c.decodeMode, data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal: %w", err)
 	}
 	c.rawDeltas = raw.Deltas
 
-	for _, opt := range opts {
-		opt(&c)
+	if raw.Deltas != nil {
+		c.Deltas = make([]did.Delta, len(raw.Deltas))
+	}
+	for i, rawDelta := range raw.Deltas {
+		if err := json.Unmarshal(rawDelta, &c.Deltas[i]); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal delta %d: %w", i, classifyDecodeError(err))
+		}
 	}
 
 	return &c, nil
@@ -70,6 +94,9 @@ type ChunkFile struct {
 	// order/length), used only for the canonicalized size check.
 	rawDeltas []json.RawMessage
 
+	decodeMode DecodeMode
+	log        *slog.Logger
+
 	createMappingArray  []string
 	recoverMappingArray []string
 	updateMappingArray  []string
@@ -80,7 +107,7 @@ type ChunkFile struct {
 }
 
 func (c *ChunkFile) Process() error {
-	// c.processor.log.Infof("Processing chunk file %s", c.processor.ChunkFileURI)
+	c.logger().Debug("processing chunk file", "deltas", len(c.Deltas))
 	// Max Chunk File Size is enforced at fetch time
 	// (fetchChunkFile passes MaxChunkFileSizeInBytes to CAS.Get).
 
@@ -117,18 +144,33 @@ func (c *ChunkFile) Process() error {
 			return err
 		}
 		id := mappingArray[i]
-		c.setDelta(id, delta)
+		if err := c.setDelta(id, delta); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (c *ChunkFile) setDelta(id string, delta did.Delta) {
-	if createOp, ok := c.createOps[id]; ok {
+func (c *ChunkFile) logger() *slog.Logger {
+	if c.log == nil {
+		return discardLogger
+	}
+	return c.log
+}
+
+func (c *ChunkFile) setDelta(id string, delta did.Delta) error {
+	if createOp, ok := c.createOps[id]; ok && createOp != nil {
 		createOp.SetDelta(delta)
-	} else if recoverOp, ok := c.recoverOps[id]; ok {
+	} else if recoverOp, ok := c.recoverOps[id]; ok && recoverOp != nil {
 		recoverOp.SetDelta(delta)
-	} else if updateOp, ok := c.updateOps[id]; ok {
+	} else if updateOp, ok := c.updateOps[id]; ok && updateOp != nil {
 		updateOp.SetDelta(delta)
+	} else {
+		// The processor builds the mapping arrays and the operations from the
+		// same index entries; only a caller passing its own through
+		// WithMappingArrays and WithOperations can leave a delta unmapped.
+		return fmt.Errorf("%w: no operation for the delta of %s", ErrMissingPrerequisite, id)
 	}
+	return nil
 }
@@ -2,6 +2,7 @@ package sidetree
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -181,10 +182,56 @@ func TestChunkFileProcess(t *testing.T) {
 				if err != nil {
 					t.Fatalf("failed to create chunk: %v", err)
 				}
-				if err := c.Process(); err != test.want {
+				if err := c.Process(); !errors.Is(err, test.want) {
 					t.Errorf("expected error %v, got %v", test.want, err)
 				}
 			})
 		}
 	})
+
+	t.Run("delta for unknown operation", func(t *testing.T) {
+		// The mapping array names "w", which no op map carries: its delta
+		// would be silently dropped, so the batch is rejected instead.
+		d := &TestSetDeltas{}
+		fileJSON, err := json.Marshal(ChunkFile{Deltas: []did.Delta{{UpdateCommitment: "abc"}, {UpdateCommitment: "def"}}})
+		if err != nil {
+			t.Fatalf("failed to marshal test data: %v", err)
+		}
+
+		c, err := NewChunkFile(
+			fileJSON,
+			WithMappingArrays([]string{"x"}, []string{"w"}, nil),
+			WithOperations(
+				map[string]operations.CreateInterface{"x": &TestDeltaCreate{t: d}},
+				map[string]operations.RecoverInterface{},
+				map[string]operations.UpdateInterface{},
+			),
+		)
+		if err != nil {
+			t.Fatalf("failed to create chunk: %v", err)
+		}
+		if err := c.Process(); !errors.Is(err, ErrMissingPrerequisite) {
+			t.Errorf("expected error %v, got %v", ErrMissingPrerequisite, err)
+		}
+	})
+
+	t.Run("nil operation", func(t *testing.T) {
+		// A nil entry in an op map has nothing to take the delta.
+		fileJSON, err := json.Marshal(ChunkFile{Deltas: []did.Delta{{UpdateCommitment: "abc"}}})
+		if err != nil {
+			t.Fatalf("failed to marshal test data: %v", err)
+		}
+
+		c, err := NewChunkFile(
+			fileJSON,
+			WithMappingArrays([]string{"x"}, nil, nil),
+			WithOperations(map[string]operations.CreateInterface{"x": nil}, nil, nil),
+		)
+		if err != nil {
+			t.Fatalf("failed to create chunk: %v", err)
+		}
+		if err := c.Process(); !errors.Is(err, ErrMissingPrerequisite) {
+			t.Errorf("expected error %v, got %v", ErrMissingPrerequisite, err)
+		}
+	})
 }
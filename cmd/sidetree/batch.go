@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	sidetree "github.com/13x-tech/sidetree-go"
+	"github.com/13x-tech/sidetree-go/localcas"
+)
+
+func runBatch(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "build" {
+		fmt.Fprintf(stderr, "Usage: sidetree batch build [flags] [file]\n")
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("batch build", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	casDir := fs.String("cas", ".", "directory to write the CAS objects to, created if missing")
+	writerLockID := fs.String("writer-lock-id", "", "writerLockId of the core index file, needed above the free operation limit")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sidetree batch build [flags] [file]\n\n"+
+			"Reads operation requests, one JSON object per line, from file or standard\n"+
+			"input, writes the batch files to the CAS directory and prints the anchor\n"+
+			"string.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	in := stdin
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "sidetree: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ops, err := readOperations(in)
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitMalformed
+	}
+
+	var opts []sidetree.BatchOption
+	if *writerLockID != "" {
+		opts = append(opts, sidetree.WithBatchWriterLockID(*writerLockID))
+	}
+	batch, err := sidetree.NewBatch(ops, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitMalformed
+	}
+
+	cas := localcas.NewDir(*casDir)
+	if err := cas.Start(); err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitUsage
+	}
+	defer cas.Close()
+
+	anchor, err := batch.Write(cas)
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitMalformed
+	}
+	fmt.Fprintln(stdout, anchor)
+	return exitOK
+}
+
+// readOperations strictly parses one operation request per non-blank line
+// (see sidetree.ParseOperationRequest).
+func readOperations(r io.Reader) ([]interface{}, error) {
+	var ops []interface{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), sidetree.MaxChunkFileSizeInBytes)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		op, err := sidetree.ParseOperationRequest(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
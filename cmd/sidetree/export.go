@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	sidetree "github.com/13x-tech/sidetree-go"
+	"github.com/13x-tech/sidetree-go/localcas"
+)
+
+func runExport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var flags anchorFlags
+	fs.StringVar(&flags.casDir, "cas", ".", "directory holding the CAS objects, one file per URI")
+	fs.StringVar(&flags.prefix, "prefix", "ion", "DID method prefix")
+	fs.StringVar(&flags.sequence, "sequence", "", "anchor sequence (transaction position) passed to the processor")
+	fs.BoolVar(&flags.lenient, "lenient", false, "decode files leniently (ignore unknown and duplicate properties)")
+	out := fs.String("o", "", "write the archive to this file instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sidetree export [flags] <anchor>\n\n"+
+			"Writes every file the anchor references to a CARv1 archive, which\n"+
+			"inspect and validate read back with -car.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cas, err := flags.openCAS()
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitUsage
+	}
+	defer cas.Close()
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "sidetree: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		w = f
+	}
+
+	anchor := operations.Anchor{
+		Anchor:   operations.AnchorString(fs.Arg(0)),
+		Sequence: operations.SequenceSignature(flags.sequence),
+	}
+	err = localcas.ExportCAR(w, anchor, cas, flags.options()...)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, sidetree.ErrContentUnavailable):
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitUnavailable
+	default:
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitMalformed
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	sidetree "github.com/13x-tech/sidetree-go"
+)
+
+// report is the result of processing one anchor.
+type report struct {
+	Anchor   string                 `json:"anchor"`
+	Sequence string                 `json:"sequence,omitempty"`
+	Outcome  sidetree.AnchorOutcome `json:"outcome"`
+	Error    string                 `json:"error,omitempty"`
+	// Reason is the most specific sentinel the error matches.
+	Reason     string                         `json:"reason,omitempty"`
+	Files      []fileReport                   `json:"files,omitempty"`
+	Operations map[sidetree.OperationType]int `json:"operations"`
+	// InvalidOperations maps each operation rejected on its own to why.
+	InvalidOperations map[string]string `json:"invalidOperations,omitempty"`
+}
+
+// fileReport describes one Sidetree file of the batch, in fetch order.
+type fileReport struct {
+	Type sidetree.FileType `json:"type"`
+	URI  string            `json:"uri"`
+	Size int               `json:"size"`
+	// Links are the URIs of the files this one references.
+	Links   []string        `json:"links,omitempty"`
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
+// inspect processes anchor against cas and reports every file fetched along
+// the way. The error is only for failures to run the processor at all; a
+// rejected batch is reported in the returned report.
+func inspect(anchor operations.Anchor, cas sidetree.CAS, opts ...sidetree.SideTreeOption) (*report, error) {
+	recorder := &fileRecorder{CAS: cas, content: map[string][]byte{}}
+	opts = append(opts, sidetree.WithCAS(recorder), sidetree.WithHooks(recorder))
+
+	p, err := sidetree.Processor(anchor, opts...)
+	if err != nil {
+		return nil, err
+	}
+	got := p.Process()
+
+	r := &report{
+		Anchor:   string(anchor.Anchor),
+		Sequence: string(anchor.Sequence),
+		Outcome:  sidetree.AnchorOutcomeOf(got.Error),
+		Files:    recorder.files,
+		Operations: map[sidetree.OperationType]int{
+			sidetree.OperationCreate:     len(got.CreateOps),
+			sidetree.OperationRecover:    len(got.RecoverOps),
+			sidetree.OperationUpdate:     len(got.UpdateOps),
+			sidetree.OperationDeactivate: len(got.DeactivateOps),
+		},
+	}
+	if got.Error != nil {
+		r.Error = got.Error.Error()
+		r.Reason = sidetree.RejectionReason(got.Error)
+	}
+	for id, result := range got.Results {
+		if !result.Valid() {
+			if r.InvalidOperations == nil {
+				r.InvalidOperations = map[string]string{}
+			}
+			r.InvalidOperations[id] = result.Reason.Error()
+		}
+	}
+	for i := range r.Files {
+		f := &r.Files[i]
+		content := recorder.content[f.URI]
+		f.Links = links(content)
+		if json.Valid(content) {
+			f.Content = content
+		} else {
+			f.Content, _ = json.Marshal(string(content))
+		}
+	}
+
+	return r, nil
+}
+
+// fileRecorder wraps a CAS to keep the content of every file it returns, and
+// observes the processor to learn the type of each.
+type fileRecorder struct {
+	sidetree.CAS
+	sidetree.NopHooks
+
+	content map[string][]byte
+	files   []fileReport
+}
+
+func (r *fileRecorder) Get(id string, maxSizeInBytes int) ([]byte, error) {
+	data, err := r.CAS.Get(id, maxSizeInBytes)
+	if err == nil {
+		r.content[id] = data
+	}
+	return data, err
+}
+
+func (r *fileRecorder) OnFileFetched(_ operations.Anchor, file sidetree.FileType, uri string, size int) {
+	r.files = append(r.files, fileReport{Type: file, URI: uri, Size: size})
+}
+
+// links returns the file URIs a Sidetree file references, decoding only the
+// reference properties so that it works on files the processor rejected.
+func links(content []byte) []string {
+	var refs struct {
+		ProvisionalIndexFileURI string `json:"provisionalIndexFileUri"`
+		CoreProofFileURI        string `json:"coreProofFileUri"`
+		ProvisionalProofFileURI string `json:"provisionalProofFileUri"`
+		Chunks                  []struct {
+			ChunkFileURI string `json:"chunkFileUri"`
+		} `json:"chunks"`
+	}
+	if err := json.Unmarshal(content, &refs); err != nil {
+		return nil
+	}
+
+	var uris []string
+	for _, uri := range []string{refs.ProvisionalIndexFileURI, refs.CoreProofFileURI, refs.ProvisionalProofFileURI} {
+		if uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	for _, chunk := range refs.Chunks {
+		if chunk.ChunkFileURI != "" {
+			uris = append(uris, chunk.ChunkFileURI)
+		}
+	}
+	return uris
+}
+
+func (r *report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeText prints the report for a person. withContent adds the decoded
+// content of every file.
+func (r *report) writeText(w io.Writer, withContent bool) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "anchor:   %s\n", r.Anchor)
+	if r.Sequence != "" {
+		fmt.Fprintf(&buf, "sequence: %s\n", r.Sequence)
+	}
+	if r.Error == "" {
+		fmt.Fprintf(&buf, "result:   %s\n", r.Outcome)
+	} else {
+		fmt.Fprintf(&buf, "result:   %s (%s)\n", r.Outcome, r.Reason)
+		fmt.Fprintf(&buf, "error:    %s\n", r.Error)
+	}
+
+	if len(r.Files) > 0 {
+		fmt.Fprintf(&buf, "\nfiles:\n")
+		tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		for _, f := range r.Files {
+			fmt.Fprintf(tw, "  %s\t%s\t%d bytes", f.Type, f.URI, f.Size)
+			if len(f.Links) > 0 {
+				fmt.Fprintf(tw, "\t-> %s", strings.Join(f.Links, ", "))
+			}
+			fmt.Fprintln(tw)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(&buf, "\noperations:\n")
+	for _, opType := range []sidetree.OperationType{
+		sidetree.OperationCreate,
+		sidetree.OperationRecover,
+		sidetree.OperationUpdate,
+		sidetree.OperationDeactivate,
+	} {
+		fmt.Fprintf(&buf, "  %-10s %d\n", opType, r.Operations[opType])
+	}
+
+	if len(r.InvalidOperations) > 0 {
+		fmt.Fprintf(&buf, "\ninvalid operations:\n")
+		ids := make([]string, 0, len(r.InvalidOperations))
+		for id := range r.InvalidOperations {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Fprintf(&buf, "  %s: %s\n", id, r.InvalidOperations[id])
+		}
+	}
+
+	if withContent {
+		for _, f := range r.Files {
+			fmt.Fprintf(&buf, "\n%s %s:\n", f.Type, f.URI)
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, f.Content, "  ", "  "); err != nil {
+				indented.Write(f.Content)
+			}
+			fmt.Fprintf(&buf, "  %s\n", indented.String())
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,194 @@
+// Command sidetree builds, inspects, validates and exports Sidetree batches
+// stored locally.
+//
+// Usage:
+//
+//	sidetree inspect [flags] <anchor>
+//	sidetree validate [flags] <anchor>
+//	sidetree export [flags] <anchor>
+//	sidetree batch build [flags] [file]
+//
+// inspect and validate run the anchor through the same OperationsProcessor a
+// node uses, reading every file from a local CAS. inspect prints the decoded
+// file graph, the size of each file, the resolved operations by type and the
+// classified error; validate prints only the verdict. Pass -json for machine
+// readable output, and -car to read the files from a CAR archive instead of
+// a directory.
+//
+// export writes every file an anchor references to a single CARv1 archive,
+// for moving a batch between machines or attaching it to a bug report.
+//
+// batch build is the writer side: it reads operation requests, one JSON
+// object per line as ion-sdk-go serializes them, checks them against the
+// protocol limits, writes the batch files to the CAS directory and prints the
+// anchor string to pass to inspect or validate.
+//
+// The exit status is 0 for a valid batch, 1 for a malformed one, 3 when a
+// file is missing from the CAS (the batch may become valid once it is
+// published) and 2 for a usage error.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	sidetree "github.com/13x-tech/sidetree-go"
+	"github.com/13x-tech/sidetree-go/localcas"
+)
+
+const (
+	exitOK          = 0
+	exitMalformed   = 1
+	exitUsage       = 2
+	exitUnavailable = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "inspect":
+		return runAnchor("inspect", args[1:], stdout, stderr)
+	case "validate":
+		return runAnchor("validate", args[1:], stdout, stderr)
+	case "export":
+		return runExport(args[1:], stdout, stderr)
+	case "batch":
+		return runBatch(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "sidetree: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+  sidetree inspect [flags] <anchor>    print the file graph, sizes, operations and verdict
+  sidetree validate [flags] <anchor>   print the verdict only
+  sidetree export [flags] <anchor>     write the files of the anchor to a CAR archive
+  sidetree batch build [flags] [file]  write operation requests as a batch and print its anchor
+
+Run "sidetree <command> -h" for the flags of a command.
+`)
+}
+
+// anchorFlags are the flags shared by the commands that process an anchor.
+type anchorFlags struct {
+	casDir           string
+	car              string
+	prefix           string
+	sequence         string
+	lenient          bool
+	verifySignatures bool
+	json             bool
+}
+
+func (f *anchorFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.casDir, "cas", ".", "directory holding the CAS objects, one file per URI")
+	fs.StringVar(&f.car, "car", "", "read the CAS objects from this CAR archive instead of -cas")
+	fs.StringVar(&f.prefix, "prefix", "ion", "DID method prefix")
+	fs.StringVar(&f.sequence, "sequence", "", "anchor sequence (transaction position) passed to the processor")
+	fs.BoolVar(&f.lenient, "lenient", false, "decode files leniently (ignore unknown and duplicate properties)")
+	fs.BoolVar(&f.verifySignatures, "verify-signatures", false, "verify the signed data of every operation")
+	fs.BoolVar(&f.json, "json", false, "print JSON instead of text")
+}
+
+func (f *anchorFlags) options() []sidetree.SideTreeOption {
+	opts := []sidetree.SideTreeOption{
+		sidetree.WithPrefix(f.prefix),
+		sidetree.WithSignatureVerification(f.verifySignatures),
+	}
+	if f.lenient {
+		opts = append(opts, sidetree.WithDecodeMode(sidetree.DecodeLenient))
+	}
+	return opts
+}
+
+func (f *anchorFlags) openCAS() (sidetree.CAS, error) {
+	if f.car != "" {
+		return localcas.OpenCAR(f.car)
+	}
+	info, err := os.Stat(f.casDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", f.casDir)
+	}
+	return localcas.NewDir(f.casDir), nil
+}
+
+func runAnchor(command string, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var flags anchorFlags
+	flags.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sidetree %s [flags] <anchor>\n\nFlags:\n", command)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cas, err := flags.openCAS()
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitUsage
+	}
+	defer cas.Close()
+
+	anchor := operations.Anchor{
+		Anchor:   operations.AnchorString(fs.Arg(0)),
+		Sequence: operations.SequenceSignature(flags.sequence),
+	}
+	r, err := inspect(anchor, cas, flags.options()...)
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitUsage
+	}
+
+	if command == "validate" {
+		r.Files = nil
+	}
+	if flags.json {
+		err = r.writeJSON(stdout)
+	} else {
+		err = r.writeText(stdout, command == "inspect")
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "sidetree: %v\n", err)
+		return exitUsage
+	}
+
+	switch r.Outcome {
+	case sidetree.AnchorOK:
+		return exitOK
+	case sidetree.AnchorUnavailable:
+		return exitUnavailable
+	default:
+		return exitMalformed
+	}
+}
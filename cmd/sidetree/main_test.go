@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	"github.com/13x-tech/sidetree-go/localcas"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// writeCAS writes files, keyed by URI, into a temporary CAS directory.
+func writeCAS(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for uri, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, uri), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// The CIDs the CAS fixtures store core index files under.
+var (
+	coreIndexCID = localcas.CID([]byte("core-index"))
+	malformedCID = localcas.CID([]byte("malformed"))
+	missingCID   = localcas.CID([]byte("missing"))
+)
+
+// createRequest returns a valid create request, one line of batch build
+// input, whose commitments are derived from seed.
+func createRequest(t *testing.T, seed string) string {
+	t.Helper()
+	commitment := func(data string) string {
+		digest := sha256.Sum256([]byte(data))
+		encoded, err := mh.Encode(digest[:], mh.SHA2_256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(encoded)
+	}
+	delta := did.Delta{Patches: []map[string]interface{}{}, UpdateCommitment: commitment(seed + "-update")}
+	deltaHash, err := delta.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := operations.CreateOperation(did.SuffixData{DeltaHash: deltaHash, RecoveryCommitment: commitment(seed + "-recovery")})
+	op.SetDelta(delta)
+	b, err := json.Marshal(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRun(t *testing.T) {
+	dir := writeCAS(t, map[string]string{
+		coreIndexCID: `{"coreProofFileUri":"proof","operations":{"deactivate":[{"didSuffix":"a","revealValue":"r"}]}}`,
+		"proof":      `{"operations":{"deactivate":[{"signedData":"x"}]}}`,
+		malformedCID: `{"operations":{},"unexpected":1}`,
+	})
+
+	tests := map[string]struct {
+		args       []string
+		wantCode   int
+		wantStdout []string
+		wantStderr string
+	}{
+		"inspect valid batch": {
+			args:     []string{"inspect", "-cas", dir, "1." + coreIndexCID},
+			wantCode: exitOK,
+			wantStdout: []string{
+				"result:   ok",
+				"core_index  " + coreIndexCID + "  94 bytes  -> proof",
+				"core_proof  proof" + strings.Repeat(" ", len(coreIndexCID)-len("proof")) + "  50 bytes",
+				"deactivate 1",
+				`"didSuffix": "a"`,
+			},
+		},
+		"validate malformed batch": {
+			args:       []string{"validate", "-cas", dir, "1." + malformedCID},
+			wantCode:   exitMalformed,
+			wantStdout: []string{"result:   malformed (ErrUnknownProperty)", "error:"},
+		},
+		"validate lenient": {
+			args:       []string{"validate", "-cas", dir, "-lenient", "1." + malformedCID},
+			wantCode:   exitOK,
+			wantStdout: []string{"result:   ok"},
+		},
+		"validate missing file": {
+			args:       []string{"validate", "-cas", dir, "1." + missingCID},
+			wantCode:   exitUnavailable,
+			wantStdout: []string{"result:   unavailable (ErrContentUnavailable)"},
+		},
+		"no command": {
+			args:       nil,
+			wantCode:   exitUsage,
+			wantStderr: "Usage:",
+		},
+		"unknown command": {
+			args:       []string{"resolve"},
+			wantCode:   exitUsage,
+			wantStderr: `unknown command "resolve"`,
+		},
+		"missing anchor": {
+			args:       []string{"inspect", "-cas", dir},
+			wantCode:   exitUsage,
+			wantStderr: "Usage: sidetree inspect",
+		},
+		"missing cas directory": {
+			args:       []string{"inspect", "-cas", filepath.Join(dir, "nope"), "1." + coreIndexCID},
+			wantCode:   exitUsage,
+			wantStderr: "no such file or directory",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(test.args, nil, &stdout, &stderr); code != test.wantCode {
+				t.Errorf("expected exit code %d, got %d\nstdout: %s\nstderr: %s", test.wantCode, code, stdout.String(), stderr.String())
+			}
+			for _, want := range test.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("expected stdout to contain %q, got\n%s", want, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), test.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got\n%s", test.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	dir := writeCAS(t, map[string]string{
+		coreIndexCID: `{"coreProofFileUri":"proof","operations":{"deactivate":[{"didSuffix":"a","revealValue":"r"}]}}`,
+		"proof":      `{"operations":{"deactivate":[{"signedData":"x"}]}}`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"inspect", "-json", "-cas", dir, "1." + coreIndexCID}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d: %s", exitOK, code, stderr.String())
+	}
+
+	var got report
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON report: %v\n%s", err, stdout.String())
+	}
+	if got.Outcome != "ok" || got.Operations["deactivate"] != 1 {
+		t.Errorf("unexpected report %+v", got)
+	}
+	if len(got.Files) != 2 || got.Files[0].URI != coreIndexCID || got.Files[0].Links[0] != "proof" || got.Files[1].Size != 50 {
+		t.Errorf("unexpected file graph %+v", got.Files)
+	}
+}
+
+func TestRunBatchBuild(t *testing.T) {
+	requests := strings.Join([]string{createRequest(t, "a"), ``, createRequest(t, "b")}, "\n")
+
+	tests := map[string]struct {
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStderr string
+	}{
+		"build": {
+			args:     []string{"batch", "build"},
+			stdin:    requests,
+			wantCode: exitOK,
+		},
+		"invalid request": {
+			args:       []string{"batch", "build"},
+			stdin:      `{"type":"migrate"}`,
+			wantCode:   exitMalformed,
+			wantStderr: "line 1",
+		},
+		"unsigned deactivate": {
+			args:       []string{"batch", "build"},
+			stdin:      `{"type":"deactivate","didSuffix":"a","revealValue":"r","signedData":"x"}`,
+			wantCode:   exitMalformed,
+			wantStderr: "line 1",
+		},
+		"duplicate suffix": {
+			args:       []string{"batch", "build"},
+			stdin:      requests + "\n" + createRequest(t, "b"),
+			wantCode:   exitMalformed,
+			wantStderr: "duplicate operation",
+		},
+		"empty input": {
+			args:       []string{"batch", "build"},
+			wantCode:   exitMalformed,
+			wantStderr: "batch has no operations",
+		},
+		"missing subcommand": {
+			args:       []string{"batch"},
+			wantCode:   exitUsage,
+			wantStderr: "Usage: sidetree batch build",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "cas")
+			args := append(test.args, "-cas", dir)
+			if len(test.args) < 2 {
+				args = test.args
+			}
+
+			var stdout, stderr bytes.Buffer
+			if code := run(args, strings.NewReader(test.stdin), &stdout, &stderr); code != test.wantCode {
+				t.Fatalf("expected exit code %d, got %d\nstderr: %s", test.wantCode, code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), test.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got\n%s", test.wantStderr, stderr.String())
+			}
+			if test.wantCode != exitOK {
+				return
+			}
+
+			anchor := strings.TrimSpace(stdout.String())
+			if !strings.HasPrefix(anchor, "2.") {
+				t.Fatalf("expected an anchor declaring 2 operations, got %q", anchor)
+			}
+			stdout.Reset()
+			if code := run([]string{"validate", "-cas", dir, anchor}, nil, &stdout, &stderr); code != exitOK {
+				t.Errorf("expected built batch to validate, got exit code %d\n%s", code, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cas")
+	var stdout, stderr bytes.Buffer
+	build := createRequest(t, "a")
+	if code := run([]string{"batch", "build", "-cas", dir}, strings.NewReader(build), &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected batch build to succeed, got exit code %d: %s", code, stderr.String())
+	}
+	anchor := strings.TrimSpace(stdout.String())
+	archive := filepath.Join(t.TempDir(), "batch.car")
+
+	// The cases run in order: validate reads the archive export wrote.
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:     "export",
+			args:     []string{"export", "-cas", dir, "-o", archive, anchor},
+			wantCode: exitOK,
+		},
+		{
+			name:       "validate from archive",
+			args:       []string{"validate", "-car", archive, anchor},
+			wantCode:   exitOK,
+			wantStdout: "result:   ok",
+		},
+		{
+			name:       "export missing file",
+			args:       []string{"export", "-cas", dir, "-o", filepath.Join(t.TempDir(), "missing.car"), "1." + localcas.CID([]byte("missing"))},
+			wantCode:   exitUnavailable,
+			wantStderr: "content unavailable",
+		},
+		{
+			name:       "missing archive",
+			args:       []string{"validate", "-car", filepath.Join(dir, "nope.car"), anchor},
+			wantCode:   exitUsage,
+			wantStderr: "no such file or directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(test.args, nil, &stdout, &stderr); code != test.wantCode {
+				t.Fatalf("expected exit code %d, got %d\nstdout: %s\nstderr: %s", test.wantCode, code, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), test.wantStdout) {
+				t.Errorf("expected stdout to contain %q, got\n%s", test.wantStdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), test.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got\n%s", test.wantStderr, stderr.String())
+			}
+		})
+	}
+}
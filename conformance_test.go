@@ -0,0 +1,156 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// conformanceVector is the vector.json of a directory under
+// testdata/conformance. Every other file in the directory is a CAS object,
+// named by its CID.
+type conformanceVector struct {
+	Description string `json:"description"`
+	// Source is where the expected outcome comes from: the reference file
+	// whose checks the vector exercises, or why a go-only vector has none.
+	Source string `json:"source"`
+	Anchor string `json:"anchor"`
+	// Options override the reference configuration the vectors run with:
+	// strict decoding and CheckReferenceMultihashes. A decode mode the
+	// reference does not have is only allowed under go-only/.
+	Options struct {
+		DecodeMode       string `json:"decodeMode"`
+		VerifySignatures bool   `json:"verifySignatures"`
+	} `json:"options"`
+
+	Outcome AnchorOutcome `json:"outcome"`
+	// Reason is the RejectionReason of the batch error.
+	Reason string `json:"reason"`
+	// Operations lists the DID suffixes resolved, by type.
+	Operations map[OperationType][]string `json:"operations"`
+	// InvalidOperations maps each operation rejected on its own to the
+	// RejectionReason of its error.
+	InvalidOperations map[string]string `json:"invalidOperations"`
+}
+
+// TestConformance runs the vectors in testdata/conformance: batches the
+// reference implementation accepts under accepted/, batches it rejects under
+// rejected/, and under go-only/ batches processed with options the reference
+// has no equivalent of. Add a vector by adding a directory.
+func TestConformance(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "conformance", "*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		t.Fatal("no conformance vectors found")
+	}
+
+	for _, dir := range dirs {
+		name, _ := filepath.Rel(filepath.Join("testdata", "conformance"), dir)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			runConformanceVector(t, dir)
+		})
+	}
+}
+
+func runConformanceVector(t *testing.T, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vector conformanceVector
+	cas := NewTestCAS()
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Name() == "vector.json" {
+			if err := json.Unmarshal(data, &vector); err != nil {
+				t.Fatalf("invalid vector.json: %v", err)
+			}
+			continue
+		}
+		if err := cas.insertObject(entry.Name(), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if vector.Source == "" {
+		t.Fatal("vector.json has no source")
+	}
+
+	opts := []SideTreeOption{
+		WithCAS(cas),
+		WithPrefix("ion"),
+		WithMultihashValidation(CheckReferenceMultihashes),
+		WithSignatureVerification(vector.Options.VerifySignatures),
+	}
+	switch vector.Options.DecodeMode {
+	case "":
+	case "lenient":
+		if filepath.Base(filepath.Dir(dir)) != "go-only" {
+			t.Fatal("a lenient vector belongs under go-only/")
+		}
+		opts = append(opts, WithDecodeMode(DecodeLenient))
+	default:
+		t.Fatalf("unknown decode mode %q", vector.Options.DecodeMode)
+	}
+
+	p, err := Processor(operations.Anchor{Anchor: operations.AnchorString(vector.Anchor)}, opts...)
+	if err != nil {
+		t.Fatalf("expected no error creating processor, got %v", err)
+	}
+	got := p.Process()
+
+	if outcome := AnchorOutcomeOf(got.Error); outcome != vector.Outcome {
+		t.Fatalf("%s\nexpected outcome %s, got %s (%v)", vector.Description, vector.Outcome, outcome, got.Error)
+	}
+	if got.Error != nil {
+		if reason := RejectionReason(got.Error); reason != vector.Reason {
+			t.Errorf("%s\nexpected reason %s, got %s (%v)", vector.Description, vector.Reason, reason, got.Error)
+		}
+		return
+	}
+
+	gotOps := map[OperationType][]string{}
+	add := func(opType OperationType, suffixes []string) {
+		if len(suffixes) > 0 {
+			gotOps[opType] = suffixes
+		}
+	}
+	add(OperationCreate, sortedKeys(got.CreateOps))
+	add(OperationRecover, sortedKeys(got.RecoverOps))
+	add(OperationUpdate, sortedKeys(got.UpdateOps))
+	add(OperationDeactivate, sortedKeys(got.DeactivateOps))
+	wantOps := map[OperationType][]string{}
+	for opType, suffixes := range vector.Operations {
+		if len(suffixes) > 0 {
+			wantOps[opType] = append([]string(nil), suffixes...)
+			sort.Strings(wantOps[opType])
+		}
+	}
+	if !reflect.DeepEqual(gotOps, wantOps) {
+		t.Errorf("%s\nexpected operations %v, got %v", vector.Description, wantOps, gotOps)
+	}
+
+	gotInvalid := map[string]string{}
+	for suffix, result := range got.Results {
+		if !result.Valid() {
+			gotInvalid[suffix] = RejectionReason(result.Reason)
+		}
+	}
+	wantInvalid := vector.InvalidOperations
+	if wantInvalid == nil {
+		wantInvalid = map[string]string{}
+	}
+	if !reflect.DeepEqual(gotInvalid, wantInvalid) {
+		t.Errorf("%s\nexpected invalid operations %v, got %v", vector.Description, wantInvalid, gotInvalid)
+	}
+}
@@ -0,0 +1,218 @@
+package sidetree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBatchInconsistent marks a batch whose files disagree with each other about
+// its deactivate operations. Like every other structural mismatch it makes the
+// whole batch permanently invalid.
+var ErrBatchInconsistent = fmt.Errorf("batch files are inconsistent")
+
+// ConsistencyIssueKind classifies a batch-consistency issue.
+type ConsistencyIssueKind int
+
+const (
+	// IssueDeactivateNotIndexed: a deactivate suffix is missing from the core
+	// index file's suffix map, so it escaped duplicate-suffix detection.
+	IssueDeactivateNotIndexed ConsistencyIssueKind = iota + 1
+
+	// IssueDeactivateHasDelta: a deactivate suffix appears in the operation
+	// delta mapping array, so a chunk file delta would be bound to it.
+	// Deactivate operations never carry a delta.
+	IssueDeactivateHasDelta
+
+	// IssueDeactivateUnproven: a deactivate entry of the core index file has
+	// no matching core proof operation.
+	IssueDeactivateUnproven
+
+	// IssueDeltaCountMismatch: the chunk file carries a different number of
+	// deltas than the create, recover and update entries account for. An
+	// extra delta has no operation to belong to but a deactivate.
+	IssueDeltaCountMismatch
+
+	// IssueDeactivateNotCreated: the resolver (WithResolver) does not hold
+	// the DID a deactivate names. The DID may still be created by an anchor
+	// published late, so this is reported and nothing more.
+	IssueDeactivateNotCreated
+
+	// IssueDeactivateAlreadyDeactivated: the resolver holds the DID a
+	// deactivate names as deactivated. Reported and nothing more, like
+	// IssueDeactivateNotCreated.
+	IssueDeactivateAlreadyDeactivated
+)
+
+func (k ConsistencyIssueKind) String() string {
+	switch k {
+	case IssueDeactivateNotIndexed:
+		return "deactivate-not-indexed"
+	case IssueDeactivateHasDelta:
+		return "deactivate-has-delta"
+	case IssueDeactivateUnproven:
+		return "deactivate-unproven"
+	case IssueDeltaCountMismatch:
+		return "delta-count-mismatch"
+	case IssueDeactivateNotCreated:
+		return "deactivate-not-created"
+	case IssueDeactivateAlreadyDeactivated:
+		return "deactivate-already-deactivated"
+	default:
+		return fmt.Sprintf("ConsistencyIssueKind(%d)", int(k))
+	}
+}
+
+// StateDependent reports whether issues of kind k depend on the DID state of
+// the resolver rather than on the batch files alone. Only the batch files
+// decide whether a batch is valid.
+func (k ConsistencyIssueKind) StateDependent() bool {
+	return k == IssueDeactivateNotCreated || k == IssueDeactivateAlreadyDeactivated
+}
+
+// ConsistencyIssue is one inconsistency found in a batch. DIDSuffix is empty
+// for issues that concern the batch as a whole.
+type ConsistencyIssue struct {
+	Kind      ConsistencyIssueKind
+	DIDSuffix string
+	Detail    string
+}
+
+func (i ConsistencyIssue) Error() string {
+	msg := fmt.Sprintf("%s: %s", i.Unwrap(), i.Kind)
+	if i.DIDSuffix != "" {
+		msg += " " + i.DIDSuffix
+	}
+	if i.Detail != "" {
+		msg += ": " + i.Detail
+	}
+	return msg
+}
+
+// Unwrap returns ErrBatchInconsistent for an issue between the batch files,
+// and ErrDIDNotFound or ErrDIDDeactivated for a state-dependent one.
+func (i ConsistencyIssue) Unwrap() error {
+	switch i.Kind {
+	case IssueDeactivateNotCreated:
+		return ErrDIDNotFound
+	case IssueDeactivateAlreadyDeactivated:
+		return ErrDIDDeactivated
+	default:
+		return ErrBatchInconsistent
+	}
+}
+
+// ConsistencyReport lists every inconsistency CheckBatchConsistency found, in
+// the order the checks ran.
+type ConsistencyReport struct {
+	Issues []ConsistencyIssue
+}
+
+// OK reports whether the batch is consistent.
+func (r ConsistencyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns nil for a consistent batch, otherwise an error joining every
+// issue. errors.As finds the first ConsistencyIssue.
+func (r ConsistencyReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	errs := make([]error, len(r.Issues))
+	for i, issue := range r.Issues {
+		errs[i] = issue
+	}
+	return errors.Join(errs...)
+}
+
+// WithResolver has CheckBatchConsistency also check the deactivate operations
+// of each batch against resolver, the DID state of the anchors applied so far.
+// A State is such a resolver.
+func WithResolver(resolver Resolver) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.resolver = resolver
+		case *OperationsProcessor:
+			t.resolver = resolver
+		}
+	}
+}
+
+// CheckBatchConsistency cross-checks the deactivate operations of the batch
+// against the core index suffix map, the operation delta mapping array, the
+// core proof file and the chunk file, and, with a resolver configured, against
+// the DID state it holds. It reports what the files processed so far allow it
+// to check: without a chunk file there is no delta count to compare, and
+// without a provisional index file there is no mapping array. It only reports;
+// it changes nothing.
+//
+// Process rejects the batch on an issue between its files, and records the
+// state-dependent issues in ProcessedOperations.Consistency. Those are a
+// snapshot of a state that changes as anchors are applied, including anchors
+// published late, so the operations they name stay in the batch; a State
+// skips a deactivate that does not apply when it replays the DID.
+func (d *OperationsProcessor) CheckBatchConsistency() ConsistencyReport {
+	return ConsistencyReport{Issues: append(d.fileConsistencyIssues(), d.stateConsistencyIssues()...)}
+}
+
+// fileConsistencyIssues returns the issues between the files of the batch.
+func (d *OperationsProcessor) fileConsistencyIssues() []ConsistencyIssue {
+	if d.coreIndexFile == nil {
+		return nil
+	}
+	var issues []ConsistencyIssue
+	add := func(kind ConsistencyIssueKind, id string, detail string) {
+		issues = append(issues, ConsistencyIssue{Kind: kind, DIDSuffix: id, Detail: detail})
+	}
+
+	mapped := map[string]struct{}{}
+	for _, mappingArray := range [][]string{d.createMappingArray, d.recoveryMappingArray, d.updateMappingArray} {
+		for _, id := range mappingArray {
+			mapped[id] = struct{}{}
+		}
+	}
+
+	for _, op := range d.coreIndexFile.Operations.Deactivate {
+		if _, ok := d.coreIndexFile.suffixMap[op.DIDSuffix]; !ok {
+			add(IssueDeactivateNotIndexed, op.DIDSuffix, "")
+		}
+		if _, ok := mapped[op.DIDSuffix]; ok {
+			add(IssueDeactivateHasDelta, op.DIDSuffix, "")
+		}
+		if d.coreProofFile != nil {
+			if _, ok := d.deactivateOps[op.DIDSuffix]; !ok {
+				add(IssueDeactivateUnproven, op.DIDSuffix, "")
+			}
+		}
+	}
+
+	if d.chunkFile != nil {
+		want := len(d.createMappingArray) + len(d.recoveryMappingArray) + len(d.updateMappingArray)
+		if got := len(d.chunkFile.Deltas); got != want {
+			add(IssueDeltaCountMismatch, "", fmt.Sprintf("%d deltas for %d create, recover and update operations", got, want))
+		}
+	}
+
+	return issues
+}
+
+// stateConsistencyIssues returns the deactivates of the batch the resolver
+// does not hold as active DIDs. A resolver failure other than ErrDIDNotFound
+// or ErrDIDDeactivated leaves the operation unchecked.
+func (d *OperationsProcessor) stateConsistencyIssues() []ConsistencyIssue {
+	if d.coreIndexFile == nil || d.resolver == nil {
+		return nil
+	}
+	var issues []ConsistencyIssue
+	for _, op := range d.coreIndexFile.Operations.Deactivate {
+		_, err := d.resolver.Resolve(op.DIDSuffix)
+		switch {
+		case errors.Is(err, ErrDIDNotFound):
+			issues = append(issues, ConsistencyIssue{Kind: IssueDeactivateNotCreated, DIDSuffix: op.DIDSuffix})
+		case errors.Is(err, ErrDIDDeactivated):
+			issues = append(issues, ConsistencyIssue{Kind: IssueDeactivateAlreadyDeactivated, DIDSuffix: op.DIDSuffix})
+		}
+	}
+	return issues
+}
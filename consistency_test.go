@@ -0,0 +1,213 @@
+package sidetree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestCheckBatchConsistency(t *testing.T) {
+	// consistent returns processor state for a batch with one create and one
+	// deactivate, after every file has been processed.
+	consistent := func() *OperationsProcessor {
+		return &OperationsProcessor{
+			coreIndexFile: &CoreIndexFile{
+				Operations: CoreOperations{Deactivate: []Operation{{DIDSuffix: "deactivated"}}},
+				suffixMap:  map[string]struct{}{"created": {}, "deactivated": {}},
+			},
+			coreProofFile: &CoreProofFile{},
+			chunkFile:     &ChunkFile{Deltas: []did.Delta{{UpdateCommitment: "abc"}}},
+			deactivateOps: map[string]operations.DeactivateInterface{
+				"deactivated": operations.DeactivateOperation("deactivated", "reveal", "signed-data"),
+			},
+			createMappingArray: []string{"created"},
+		}
+	}
+
+	tests := map[string]struct {
+		modify func(d *OperationsProcessor)
+		want   []ConsistencyIssue
+	}{
+		"consistent": {
+			modify: func(d *OperationsProcessor) {},
+		},
+		"no core index": {
+			modify: func(d *OperationsProcessor) { d.coreIndexFile = nil },
+		},
+		"deactivate missing from suffix map": {
+			modify: func(d *OperationsProcessor) { delete(d.coreIndexFile.suffixMap, "deactivated") },
+			want:   []ConsistencyIssue{{Kind: IssueDeactivateNotIndexed, DIDSuffix: "deactivated"}},
+		},
+		"deactivate in delta mapping array": {
+			modify: func(d *OperationsProcessor) {
+				d.updateMappingArray = []string{"deactivated"}
+				d.chunkFile.Deltas = append(d.chunkFile.Deltas, did.Delta{UpdateCommitment: "def"})
+			},
+			want: []ConsistencyIssue{{Kind: IssueDeactivateHasDelta, DIDSuffix: "deactivated"}},
+		},
+		"deactivate without proof": {
+			modify: func(d *OperationsProcessor) { delete(d.deactivateOps, "deactivated") },
+			want:   []ConsistencyIssue{{Kind: IssueDeactivateUnproven, DIDSuffix: "deactivated"}},
+		},
+		"proof not yet processed": {
+			modify: func(d *OperationsProcessor) {
+				d.coreProofFile = nil
+				delete(d.deactivateOps, "deactivated")
+			},
+		},
+		"extra delta": {
+			modify: func(d *OperationsProcessor) {
+				d.chunkFile.Deltas = append(d.chunkFile.Deltas, did.Delta{UpdateCommitment: "def"})
+			},
+			want: []ConsistencyIssue{{
+				Kind:   IssueDeltaCountMismatch,
+				Detail: "2 deltas for 1 create, recover and update operations",
+			}},
+		},
+		"every issue is reported": {
+			modify: func(d *OperationsProcessor) {
+				delete(d.coreIndexFile.suffixMap, "deactivated")
+				delete(d.deactivateOps, "deactivated")
+				d.chunkFile.Deltas = nil
+			},
+			want: []ConsistencyIssue{
+				{Kind: IssueDeactivateNotIndexed, DIDSuffix: "deactivated"},
+				{Kind: IssueDeactivateUnproven, DIDSuffix: "deactivated"},
+				{Kind: IssueDeltaCountMismatch, Detail: "0 deltas for 1 create, recover and update operations"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := consistent()
+			test.modify(d)
+
+			report := d.CheckBatchConsistency()
+			if !reflect.DeepEqual(report.Issues, test.want) {
+				t.Fatalf("expected issues %v, got %v", test.want, report.Issues)
+			}
+			if len(test.want) == 0 {
+				if !report.OK() || report.Err() != nil {
+					t.Errorf("expected a consistent report, got %v", report.Err())
+				}
+				return
+			}
+
+			err := report.Err()
+			if !errors.Is(err, ErrBatchInconsistent) {
+				t.Errorf("expected %v, got %v", ErrBatchInconsistent, err)
+			}
+			var issue ConsistencyIssue
+			if !errors.As(err, &issue) || issue.Kind != test.want[0].Kind {
+				t.Errorf("expected the first issue to be %s, got %v", test.want[0].Kind, issue)
+			}
+			if !errors.Is(classifyMalformed(err), ErrMalformed) {
+				t.Errorf("expected an inconsistent batch to classify as %v", ErrMalformed)
+			}
+		})
+	}
+}
+
+func TestProcessBatchConsistency(t *testing.T) {
+	active := newTestDID(t, "active")
+	deactivated := newTestDID(t, "deactivated")
+	late := newTestDID(t, "late")
+	state := NewState("test")
+	applyAnchors(t, state, testAnchor(1, active.create, deactivated.create), testAnchor(2, deactivated.deactivate(t, deactivated.recoveryKey)))
+
+	// The batch at 200 deactivates all three DIDs; late is created by an
+	// anchor at 100 whose content is published after it.
+	cas := NewTestCAS()
+	batch, err := NewBatch([]interface{}{
+		active.deactivate(t, active.recoveryKey),
+		deactivated.deactivate(t, deactivated.recoveryKey),
+		late.deactivate(t, late.recoveryKey),
+	})
+	if err != nil {
+		t.Fatalf("failed to build batch: %v", err)
+	}
+	anchor, err := batch.Write(cas)
+	if err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+
+	tests := map[string]struct {
+		resolver Resolver
+		want     map[string]ConsistencyIssueKind
+	}{
+		"no resolver": {},
+		"resolver": {
+			resolver: state,
+			want: map[string]ConsistencyIssueKind{
+				deactivated.suffix: IssueDeactivateAlreadyDeactivated,
+				late.suffix:        IssueDeactivateNotCreated,
+			},
+		},
+		"resolver failure": {
+			resolver: failingResolver{errors.New("storage offline")},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := []SideTreeOption{WithCAS(cas), WithPrefix("test")}
+			if test.resolver != nil {
+				opts = append(opts, WithResolver(test.resolver))
+			}
+			p, err := Processor(operations.Anchor{Anchor: anchor, Sequence: NewTransactionNumber(200, 0).Sequence("block", "tx")}, opts...)
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+
+			got := p.Process()
+			if got.Error != nil {
+				t.Fatalf("a deactivate the resolver does not hold active must not fail the batch, got %v", got.Error)
+			}
+			// The state-dependent issues are reported, and the operations
+			// stay in the batch.
+			if len(got.DeactivateOps) != 3 {
+				t.Errorf("expected 3 deactivates, got %d", len(got.DeactivateOps))
+			}
+			issues := map[string]ConsistencyIssueKind{}
+			for _, issue := range got.Consistency.Issues {
+				issues[issue.DIDSuffix] = issue.Kind
+			}
+			if len(issues) != len(test.want) || (len(test.want) > 0 && !reflect.DeepEqual(issues, test.want)) {
+				t.Errorf("expected issues %v, got %v", test.want, got.Consistency.Issues)
+			}
+		})
+	}
+
+	// late's create, published after the deactivate was processed, splices
+	// in before it, and the deactivate applies.
+	p, err := Processor(operations.Anchor{Anchor: anchor, Sequence: NewTransactionNumber(200, 0).Sequence("block", "tx")},
+		WithCAS(cas), WithPrefix("test"), WithResolver(state))
+	if err != nil {
+		t.Fatalf("expected no error creating processor, got %v", err)
+	}
+	applyAnchors(t, state, p.Process(), testAnchor(100, late.create))
+	if _, err := state.Resolve(late.suffix); !errors.Is(err, ErrDIDDeactivated) {
+		t.Errorf("expected the late-created DID to be deactivated, got %v", err)
+	}
+}
+
+func TestConsistencyIssueUnwrap(t *testing.T) {
+	tests := map[ConsistencyIssueKind]error{
+		IssueDeactivateUnproven:           ErrBatchInconsistent,
+		IssueDeactivateNotCreated:         ErrDIDNotFound,
+		IssueDeactivateAlreadyDeactivated: ErrDIDDeactivated,
+	}
+	for kind, want := range tests {
+		issue := ConsistencyIssue{Kind: kind, DIDSuffix: "a"}
+		if !errors.Is(issue, want) {
+			t.Errorf("expected %s to match %v, got %v", kind, want, issue)
+		}
+		if kind.StateDependent() == (want == ErrBatchInconsistent) {
+			t.Errorf("expected %s state dependent %v", kind, want != ErrBatchInconsistent)
+		}
+	}
+}
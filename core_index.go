@@ -1,20 +1,19 @@
 package sidetree
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
 )
 
-func NewCoreIndexFile(processor *OperationsProcessor, data []byte) (*CoreIndexFile, error) {
-	c := CoreIndexFile{}
-	if err := json.Unmarshal(data, &c); err != nil {
+// NewCoreIndexFile decodes a core index file. Process validates it.
+func NewCoreIndexFile(data []byte, opts ...FileOption) (*CoreIndexFile, error) {
+	c := CoreIndexFile{opts: newFileOptions(opts)}
+	if err := decodeJSON(c.opts.decodeMode, data, &c); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal core index file: %w", err)
 	}
 
-	c.processor = processor
-
 	return &c, nil
 }
 
@@ -24,13 +23,18 @@ type CoreIndexFile struct {
 	WriterLockId        string         `json:"writerLockId,omitempty"`
 	Operations          CoreOperations `json:"operations"`
 
-	suffixMap map[string]struct{}
+	opts fileOptions
 
-	processor *OperationsProcessor
+	// Set by Process.
+	suffixMap          map[string]struct{}
+	createMappingArray []string
 }
 
+// Process validates the core index file on its own: the field caps, the
+// optional multihash checks, the core proof URI requirement and duplicate DID
+// suffixes. The provisional index and core proof files of the batch are
+// processed against it.
 func (c *CoreIndexFile) Process() error {
-	// c.processor.log.Infof("Processing core index file %s with %d operations", c.processor.CoreIndexFileURI, c.processor.opsCount)
 	// Core Index File Processing Procedure
 	// https://identity.foundation/sidetree/spec/#core-index-file-processing
 
@@ -49,18 +53,26 @@ func (c *CoreIndexFile) Process() error {
 		return err
 	}
 
-	c.processor.provisionalIndexFileURI = c.ProvisionalIndexURI
+	// Optional parse-time multihash checks (WithMultihashValidation).
+	for _, op := range c.Operations.Recover {
+		if err := checkOperationReference(c.opts.multihashChecks, "recover", op); err != nil {
+			return err
+		}
+	}
+	for _, op := range c.Operations.Deactivate {
+		if err := checkOperationReference(c.opts.multihashChecks, "deactivate", op); err != nil {
+			return err
+		}
+	}
 
 	if (len(c.Operations.Deactivate) > 0 || len(c.Operations.Recover) > 0) && c.CoreProofURI == "" {
 		return ErrNoCoreProof
 	}
-	c.processor.coreProofFileURI = c.CoreProofURI
 
 	if err := c.populateCoreOperationArray(); err != nil {
 		return fmt.Errorf("failed to populate core operations array: %w", err)
 	}
 
-	// TODO Need to Process Create, Recover, Deactivate Ops here?
 	return nil
 }
 
@@ -73,6 +85,7 @@ func (c *CoreIndexFile) populateCoreOperationArray() error {
 	// its files are to be ignored.
 
 	c.suffixMap = map[string]struct{}{}
+	c.createMappingArray = make([]string, 0, len(c.Operations.Create))
 
 	for _, op := range c.Operations.Create {
 		uri, _ := op.SuffixData.URI()
@@ -80,6 +93,7 @@ func (c *CoreIndexFile) populateCoreOperationArray() error {
 			return ErrDuplicateOperation
 		}
 		c.suffixMap[uri] = struct{}{}
+		c.createMappingArray = append(c.createMappingArray, uri)
 	}
 
 	for _, op := range c.Operations.Recover {
@@ -99,6 +113,39 @@ func (c *CoreIndexFile) populateCoreOperationArray() error {
 	return nil
 }
 
+// processed reports whether Process has validated the file.
+func (c *CoreIndexFile) processed() bool {
+	return c != nil && c.suffixMap != nil
+}
+
+// CreateMappingArray returns the DID suffix of each create operation, in file
+// order: the first part of the operation delta mapping array. It is nil until
+// Process succeeds.
+func (c *CoreIndexFile) CreateMappingArray() []string {
+	return c.createMappingArray
+}
+
+// RecoverMappingArray returns the DID suffix of each recover operation, in
+// file order: the second part of the operation delta mapping array.
+func (c *CoreIndexFile) RecoverMappingArray() []string {
+	suffixes := make([]string, 0, len(c.Operations.Recover))
+	for _, op := range c.Operations.Recover {
+		suffixes = append(suffixes, op.DIDSuffix)
+	}
+	return suffixes
+}
+
+// CreateOps returns a new create operation for each create entry, keyed by
+// DID suffix. The chunk file supplies their deltas. It is empty until Process
+// succeeds.
+func (c *CoreIndexFile) CreateOps() map[string]operations.CreateInterface {
+	ops := make(map[string]operations.CreateInterface, len(c.createMappingArray))
+	for i, uri := range c.createMappingArray {
+		ops[uri] = operations.CreateOperation(c.Operations.Create[i].SuffixData)
+	}
+	return ops
+}
+
 type CoreOperations struct {
 	Create     []CreateOperation `json:"create,omitempty"`
 	Recover    []Operation       `json:"recover,omitempty"`
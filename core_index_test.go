@@ -3,13 +3,15 @@ package sidetree
 import (
 	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
 )
 
 func TestBadOperationsData(t *testing.T) {
-	_, err := NewCoreIndexFile(nil, []byte("bad data"))
+	_, err := NewCoreIndexFile([]byte("bad data"))
 	if err == nil {
 		t.Errorf("should have failed to create core index file")
 	}
@@ -114,8 +116,7 @@ func TestDuplicateOperations(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index file: %w", err)
 				}
-				p := &OperationsProcessor{}
-				cif, err := NewCoreIndexFile(p, coreJson)
+				cif, err := NewCoreIndexFile(coreJson)
 				if err != nil {
 					t.Fatal("failed to create core index file: %w", err)
 				}
@@ -176,8 +177,7 @@ func TestCoreIndexProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index file: %w", err)
 				}
-				p := &OperationsProcessor{}
-				cif, err := NewCoreIndexFile(p, coreJson)
+				cif, err := NewCoreIndexFile(coreJson)
 				if err != nil {
 					t.Fatal("failed to create core index file: %w", err)
 				}
@@ -215,15 +215,7 @@ func TestCoreIndexProcess(t *testing.T) {
 					if err != nil {
 						t.Fatalf("Error marshalling test core index file: %v", err)
 					}
-					p := &OperationsProcessor{}
-
-					cif, err := NewCoreIndexFile(p, coreIndexJSON)
-					if err != nil {
-						t.Fatalf("failed to create core index file: %v", err)
-					}
-					if err := cif.Process(); err != nil {
-						t.Fatalf("unexpected error when processing file without operations or proofs: %v", err)
-					}
+					p := processCoreIndex(t, coreIndexJSON)
 
 					if p.provisionalIndexFileURI != test.IndexURI {
 						t.Fatalf("expected provisional index uri to be %s but got %s", test.IndexURI, p.provisionalIndexFileURI)
@@ -256,17 +248,7 @@ func TestCoreIndexProcess(t *testing.T) {
 						t.Errorf("Error marshalling test core index file: %v", err)
 						return
 					}
-					p := &OperationsProcessor{}
-
-					cif, err := NewCoreIndexFile(p, coreIndexJSON)
-					if err != nil {
-						t.Errorf("failed to create core index file: %v", err)
-						return
-					}
-					if err := cif.Process(); err != nil {
-						t.Errorf("unexpected error when processing file without operations or proofs: %v", err)
-						return
-					}
+					p := processCoreIndex(t, coreIndexJSON)
 					if p.coreProofFileURI != test.CoreProofURI {
 						t.Errorf("expected provisional index uri to be %s but got %s", test.CoreProofURI, p.coreProofFileURI)
 						return
@@ -276,3 +258,70 @@ func TestCoreIndexProcess(t *testing.T) {
 		})
 	})
 }
+
+// processCoreIndex runs a processor over a batch holding only the core index
+// file data, so that the processor has taken its file URIs from it.
+func processCoreIndex(t *testing.T, data []byte) *OperationsProcessor {
+	t.Helper()
+	cas := NewTestCAS()
+	if err := cas.insertObject(coreIndexCID, data); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, WithCAS(cas), WithPrefix("ion"))
+	if err != nil {
+		t.Fatalf("expected no error creating processor, got %v", err)
+	}
+	// The files the core index points to are not in the CAS.
+	if got := p.Process(); got.Error != nil && !errors.Is(got.Error, ErrContentUnavailable) {
+		t.Fatalf("unexpected error when processing file without operations or proofs: %v", got.Error)
+	}
+	return p
+}
+
+func TestCoreIndexProcessStandalone(t *testing.T) {
+	create := CreateOperation{SuffixData: did.SuffixData{DeltaHash: "abc123", RecoveryCommitment: "xyz789"}}
+	createSuffix, _ := create.SuffixData.URI()
+	data, err := json.Marshal(CoreIndexFile{
+		CoreProofURI: "core-proof",
+		Operations: CoreOperations{
+			Create:     []CreateOperation{create},
+			Recover:    []Operation{{DIDSuffix: "recover-did", RevealValue: "r"}},
+			Deactivate: []Operation{{DIDSuffix: "deactivate-did", RevealValue: "r"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCoreIndexFile(data)
+	if err != nil {
+		t.Fatalf("expected no error decoding, got %v", err)
+	}
+	if got := c.CreateMappingArray(); got != nil {
+		t.Errorf("expected no create mapping before Process, got %v", got)
+	}
+	if err := c.Process(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := c.CreateMappingArray(); !reflect.DeepEqual(got, []string{createSuffix}) {
+		t.Errorf("expected create mapping [%s], got %v", createSuffix, got)
+	}
+	if got := c.RecoverMappingArray(); !reflect.DeepEqual(got, []string{"recover-did"}) {
+		t.Errorf("expected recover mapping [recover-did], got %v", got)
+	}
+	if ops := c.CreateOps(); len(ops) != 1 || ops[createSuffix] == nil {
+		t.Errorf("expected a create operation for %s, got %v", createSuffix, ops)
+	}
+
+	// The options apply without a processor.
+	c, err = NewCoreIndexFile(data, WithFileMultihashValidation(CheckRevealValues))
+	if err != nil {
+		t.Fatalf("expected no error decoding, got %v", err)
+	}
+	if err := c.Process(); !errors.Is(err, ErrInvalidRevealValue) {
+		t.Errorf("expected %v, got %v", ErrInvalidRevealValue, err)
+	}
+	if _, err := NewCoreIndexFile([]byte(`{"unknown":1}`), WithFileDecodeMode(DecodeLenient)); err != nil {
+		t.Errorf("expected lenient decoding to ignore an unknown property, got %v", err)
+	}
+}
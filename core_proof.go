@@ -1,7 +1,6 @@
 package sidetree
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/operations"
@@ -11,12 +10,13 @@ var (
 	ErrCoreProofCount = fmt.Errorf("core proof count mismatch")
 )
 
-func NewCoreProofFile(processor *OperationsProcessor, data []byte) (*CoreProofFile, error) {
-	var c CoreProofFile
-	if err := json.Unmarshal(data, &c); err != nil {
+// NewCoreProofFile decodes a core proof file. Process validates it against
+// the batch's core index file.
+func NewCoreProofFile(data []byte, opts ...FileOption) (*CoreProofFile, error) {
+	c := CoreProofFile{opts: newFileOptions(opts)}
+	if err := decodeJSON(c.opts.decodeMode, data, &c); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal core proof file: %w", err)
 	}
-	c.processor = processor
 
 	return &c, nil
 }
@@ -24,45 +24,71 @@ func NewCoreProofFile(processor *OperationsProcessor, data []byte) (*CoreProofFi
 type CoreProofFile struct {
 	Operations CoreProofOperations `json:"operations"`
 
-	processor *OperationsProcessor
+	opts fileOptions
+
+	// Set by Process.
+	recoverOps    map[string]operations.RecoverInterface
+	deactivateOps map[string]operations.DeactivateInterface
+	signedDataResults
 }
 
-func (p *CoreProofFile) Process() error {
-	// p.processor.log.Infof("Processing core proof file %s", p.processor.CoreProofFileURI)
+// Process pairs each proof with the recover or deactivate entry at the same
+// index of coreIndex, building the recover and deactivate operations, and
+// verifies their signed data if enabled. coreIndex need not be processed.
+func (p *CoreProofFile) Process(coreIndex *CoreIndexFile) error {
+	if coreIndex == nil {
+		return missingPrerequisite("core proof file", "the core index file")
+	}
 	//TODO Check Max Core Proof File Size
 
-	if len(p.Operations.Recover) != len(p.processor.coreIndexFile.Operations.Recover) ||
-		len(p.Operations.Deactivate) != len(p.processor.coreIndexFile.Operations.Deactivate) {
+	if len(p.Operations.Recover) != len(coreIndex.Operations.Recover) ||
+		len(p.Operations.Deactivate) != len(coreIndex.Operations.Deactivate) {
 		return ErrCoreProofCount
 	}
 
+	p.recoverOps = map[string]operations.RecoverInterface{}
+	p.deactivateOps = map[string]operations.DeactivateInterface{}
+
 	for i, op := range p.Operations.Recover {
-		coreOp := p.processor.coreIndexFile.Operations.Recover[i]
-		p.setRecoverOp(coreOp.DIDSuffix, coreOp.RevealValue, op)
+		coreOp := coreIndex.Operations.Recover[i]
+		p.recoverOps[coreOp.DIDSuffix] = operations.RecoverOperation(
+			coreOp.DIDSuffix,
+			coreOp.RevealValue,
+			op.SignedData,
+		)
+		if p.opts.verifySignatures {
+			deltaHash, err := verifyRecoverSignedData(coreOp.RevealValue, op.SignedData)
+			p.record(coreOp.DIDSuffix, deltaHash, err)
+		}
 	}
 
 	for i, op := range p.Operations.Deactivate {
-		coreOp := p.processor.coreIndexFile.Operations.Deactivate[i]
-		p.setDeactivateOp(coreOp.DIDSuffix, coreOp.RevealValue, op)
+		coreOp := coreIndex.Operations.Deactivate[i]
+		p.deactivateOps[coreOp.DIDSuffix] = operations.DeactivateOperation(
+			coreOp.DIDSuffix,
+			coreOp.RevealValue,
+			op.SignedData,
+		)
+		if p.opts.verifySignatures {
+			if err := verifyDeactivateSignedData(coreOp.DIDSuffix, coreOp.RevealValue, op.SignedData); err != nil {
+				p.flag(coreOp.DIDSuffix, err)
+			}
+		}
 	}
 
 	return nil
 }
 
-func (p *CoreProofFile) setDeactivateOp(id string, revealValue string, op SignedDeactivateDataOp) {
-	p.processor.deactivateOps[id] = operations.DeactivateOperation(
-		id,
-		revealValue,
-		op.SignedData,
-	)
+// RecoverOps returns the recover operations, keyed by DID suffix. The chunk
+// file supplies their deltas. It is nil until Process succeeds.
+func (p *CoreProofFile) RecoverOps() map[string]operations.RecoverInterface {
+	return p.recoverOps
 }
 
-func (p *CoreProofFile) setRecoverOp(id string, revealValue string, op SignedRecoverDataOp) {
-	p.processor.recoverOps[id] = operations.RecoverOperation(
-		id,
-		revealValue,
-		op.SignedData,
-	)
+// DeactivateOps returns the deactivate operations, keyed by DID suffix. It is
+// nil until Process succeeds.
+func (p *CoreProofFile) DeactivateOps() map[string]operations.DeactivateInterface {
+	return p.deactivateOps
 }
 
 type CoreProofOperations struct {
@@ -2,14 +2,13 @@ package sidetree
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
-
-	"github.com/13x-tech/ion-sdk-go/pkg/operations"
 )
 
 func TestNewCoreProofFile(t *testing.T) {
 	t.Run("invalid core proof", func(t *testing.T) {
-		_, err := NewCoreProofFile(nil, []byte("invalid"))
+		_, err := NewCoreProofFile([]byte("invalid"))
 		if err == nil {
 			t.Errorf("should have failed to create core proof file")
 		}
@@ -33,7 +32,7 @@ func TestNewCoreProofFile(t *testing.T) {
 			return
 		}
 
-		_, err = NewCoreProofFile(nil, cpfJson)
+		_, err = NewCoreProofFile(cpfJson)
 		if err != nil {
 			t.Errorf("should have succeeded to create core proof file, got error: %v", err)
 		}
@@ -119,16 +118,11 @@ func TestCountOperations(t *testing.T) {
 				if err != nil {
 					t.Fatalf("failed to marshal core proof file: %v", err)
 				}
-				p := &OperationsProcessor{
-					coreIndexFile: &test.IndexFile,
-					deactivateOps: map[string]operations.DeactivateInterface{},
-					recoverOps:    map[string]operations.RecoverInterface{},
-				}
-				c, err := NewCoreProofFile(p, fileJSON)
+				c, err := NewCoreProofFile(fileJSON)
 				if err != nil {
 					t.Fatalf("failed to create core proof file: %v", err)
 				}
-				if err := c.Process(); err != test.want {
+				if err := c.Process(&test.IndexFile); err != test.want {
 					t.Errorf("expected error %v, got %v", test.want, err)
 				}
 			})
@@ -136,3 +130,42 @@ func TestCountOperations(t *testing.T) {
 
 	})
 }
+
+func TestCoreProofProcessMissingPrerequisites(t *testing.T) {
+	data := []byte(`{"operations":{"recover":[{"signedData":"x"}],"deactivate":[{"signedData":"y"}]}}`)
+	coreIndex := &CoreIndexFile{Operations: CoreOperations{
+		Recover:    []Operation{{DIDSuffix: "recover-did", RevealValue: "r"}},
+		Deactivate: []Operation{{DIDSuffix: "deactivate-did", RevealValue: "r"}},
+	}}
+
+	tests := map[string]struct {
+		coreIndex *CoreIndexFile
+		want      error
+	}{
+		"no core index": {want: ErrMissingPrerequisite},
+		// The core index need not be processed.
+		"core index only": {coreIndex: coreIndex},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := NewCoreProofFile(data)
+			if err != nil {
+				t.Fatalf("expected no error decoding, got %v", err)
+			}
+			err = f.Process(test.coreIndex)
+			if test.want != nil {
+				if !errors.Is(err, test.want) {
+					t.Errorf("expected %v, got %v", test.want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(f.RecoverOps()) != 1 || len(f.DeactivateOps()) != 1 {
+				t.Errorf("expected one recover and one deactivate, got %v and %v", f.RecoverOps(), f.DeactivateOps())
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package sidetree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Strict decoding errors. The reference implementation rejects a Sidetree file
+// that carries a property the protocol does not define, and a JSON document
+// with a repeated key is ambiguous (encoding/json silently lets the last one
+// win, other parsers may keep the first), so both make the whole batch
+// permanently invalid. Like every other parse-time failure they are returned
+// raw and classified ErrMalformed by the fetch path.
+var (
+	ErrUnknownProperty     = fmt.Errorf("unknown property")
+	ErrDuplicateProperty   = fmt.Errorf("duplicate property")
+	ErrInvalidPropertyType = fmt.Errorf("invalid property type")
+	ErrTrailingData        = fmt.Errorf("unexpected data after top-level value")
+)
+
+// DecodeMode selects how the Sidetree file constructors decode JSON.
+type DecodeMode int
+
+const (
+	// DecodeStrict rejects unknown properties (including a known one spelled
+	// in a different case), duplicate keys, values of the wrong JSON type and
+	// trailing data after the top-level value. It is the
+	// zero value, and therefore the default, because accepting a file the
+	// reference rejects is a consensus divergence.
+	//
+	// Chunk file deltas are the one exception to the unknown-property rule: the
+	// reference only size-checks each delta at batch-parse time (an unexpected
+	// delta property invalidates that one operation when it is applied, not the
+	// batch), so unknown properties inside a delta are kept out of the check.
+	// JSON null is accepted wherever encoding/json accepts it (as an absent value).
+	DecodeStrict DecodeMode = iota
+
+	// DecodeLenient is plain json.Unmarshal: unknown properties are ignored and
+	// the last of several duplicate keys wins. Only for tooling that must
+	// inspect files a compliant node would reject.
+	DecodeLenient
+)
+
+func (m DecodeMode) String() string {
+	switch m {
+	case DecodeStrict:
+		return "strict"
+	case DecodeLenient:
+		return "lenient"
+	default:
+		return fmt.Sprintf("DecodeMode(%d)", int(m))
+	}
+}
+
+// decodeJSON decodes data into v according to mode. Errors from strict
+// decoding wrap one of the sentinels above so callers can match the cause.
+func decodeJSON(mode DecodeMode, data []byte, v interface{}) error {
+	if mode == DecodeLenient {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return classifyDecodeError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrTrailingData
+	}
+
+	// Decode has validated the syntax and bounded the nesting depth, so the
+	// token walk below can only fail on a repeated or miscased key.
+	return checkKeys(data, reflect.TypeOf(v))
+}
+
+// classifyDecodeError maps encoding/json errors onto the strict sentinels. The
+// unknown-field error has no exported type, so it is matched by its message.
+func classifyDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("%w: %w", ErrInvalidPropertyType, err)
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return fmt.Errorf("%w: %w", ErrUnknownProperty, err)
+	}
+	return err
+}
+
+// checkKeys walks every object in data and rejects the first key that appears
+// twice in the same object. Objects decoded into a struct of t must also spell
+// each key exactly as its json tag: encoding/json matches field names
+// case-insensitively, so "PROVISIONALINDEXFILEURI" would otherwise fill (or
+// silently overwrite) provisionalIndexFileUri where the reference rejects it
+// as an unknown property. It keeps an explicit stack rather than recursing, so
+// attacker-controlled nesting cannot grow the goroutine stack.
+func checkKeys(data []byte, t reflect.Type) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// One entry per open container: the keys seen so far for an object (nil
+	// for an array) and the Go type it decodes into (nil when unchecked).
+	// expectKey is true when the next token in an object is a key; next is the
+	// type of the value about to be read.
+	type container struct {
+		keys map[string]struct{}
+		typ  reflect.Type
+	}
+	var stack []container
+	expectKey := false
+	next := t
+
+	// afterValue restores the state for the token following a value.
+	afterValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		expectKey = top.keys != nil
+		if !expectKey {
+			next = elemType(top.typ)
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if expectKey {
+			if delim, ok := tok.(json.Delim); ok && delim == '}' {
+				stack = stack[:len(stack)-1]
+				afterValue()
+				continue
+			}
+			key := tok.(string)
+			top := stack[len(stack)-1]
+			if _, ok := top.keys[key]; ok {
+				return fmt.Errorf("%w: %q", ErrDuplicateProperty, key)
+			}
+			top.keys[key] = struct{}{}
+			next, err = fieldType(top.typ, key)
+			if err != nil {
+				return err
+			}
+			expectKey = false
+			continue
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, container{keys: map[string]struct{}{}, typ: next})
+			expectKey = true
+		case json.Delim('['):
+			stack = append(stack, container{typ: next})
+			next = elemType(next)
+		case json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			afterValue()
+		default:
+			afterValue()
+		}
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// checkedType dereferences t and returns nil for the types whose keys are not
+// checked: anything that decodes itself and anything that is not a struct,
+// slice, array or map.
+func checkedType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return t
+	default:
+		return nil
+	}
+}
+
+// elemType returns the type of the elements of the container t decodes into.
+func elemType(t reflect.Type) reflect.Type {
+	t = checkedType(t)
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem()
+	default:
+		return nil
+	}
+}
+
+// fieldType returns the type the value of key decodes into when the object
+// decodes into t. A struct key must match a json tag exactly.
+func fieldType(t reflect.Type, key string) (reflect.Type, error) {
+	t = checkedType(t)
+	if t == nil {
+		return nil, nil
+	}
+	if t.Kind() == reflect.Map {
+		return t.Elem(), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	if f, ok := jsonField(t, key); ok {
+		return f.Type, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProperty, key)
+}
+
+// jsonField finds the exported field of the struct t, or of a struct embedded
+// in it, whose JSON name is exactly name.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldName, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && fieldName == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := jsonField(embedded, name); ok {
+					return found, true
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = f.Name
+		}
+		if fieldName == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,229 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestDecodeJSONStrict(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr error
+	}{
+		"valid": {
+			data:    `{"provisionalIndexFileUri":"uri","operations":{"recover":[{"didSuffix":"a","revealValue":"b"}]}}`,
+			wantErr: nil,
+		},
+		"null fields are accepted": {
+			data:    `{"operations":{"create":null}}`,
+			wantErr: nil,
+		},
+		"unknown top-level property": {
+			data:    `{"provisionalIndexFileUri":"uri","extra":1}`,
+			wantErr: ErrUnknownProperty,
+		},
+		"unknown nested property": {
+			data:    `{"operations":{"recover":[{"didSuffix":"a","revealValue":"b","extra":true}]}}`,
+			wantErr: ErrUnknownProperty,
+		},
+		"duplicate top-level key": {
+			data:    `{"coreProofFileUri":"a","coreProofFileUri":"b"}`,
+			wantErr: ErrDuplicateProperty,
+		},
+		"duplicate nested key": {
+			data:    `{"operations":{"deactivate":[{"didSuffix":"a","didSuffix":"b"}]}}`,
+			wantErr: ErrDuplicateProperty,
+		},
+		"keys differing only in case": {
+			data:    `{"provisionalIndexFileUri":"a","PROVISIONALINDEXFILEURI":"b"}`,
+			wantErr: ErrUnknownProperty,
+		},
+		"miscased top-level property": {
+			data:    `{"ProvisionalIndexFileUri":"a"}`,
+			wantErr: ErrUnknownProperty,
+		},
+		"miscased nested property": {
+			data:    `{"operations":{"recover":[{"didsuffix":"a","revealValue":"b"}]}}`,
+			wantErr: ErrUnknownProperty,
+		},
+		"same key in sibling objects is not a duplicate": {
+			data:    `{"operations":{"recover":[{"didSuffix":"a"},{"didSuffix":"b"}]}}`,
+			wantErr: nil,
+		},
+		"wrong type": {
+			data:    `{"coreProofFileUri":42}`,
+			wantErr: ErrInvalidPropertyType,
+		},
+		"wrong nested type": {
+			data:    `{"operations":{"recover":{"didSuffix":"a"}}}`,
+			wantErr: ErrInvalidPropertyType,
+		},
+		"trailing data": {
+			data:    `{} {}`,
+			wantErr: ErrTrailingData,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var c CoreIndexFile
+			err := decodeJSON(DecodeStrict, []byte(test.data), &c)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONLenient(t *testing.T) {
+	var c CoreIndexFile
+	data := `{"coreProofFileUri":"a","coreProofFileUri":"b","extra":1}`
+	if err := decodeJSON(DecodeLenient, []byte(data), &c); err != nil {
+		t.Fatalf("expected lenient decoding to accept %s, got %v", data, err)
+	}
+	if c.CoreProofURI != "b" {
+		t.Errorf("expected the last duplicate key to win, got %q", c.CoreProofURI)
+	}
+}
+
+func TestChunkFileStrictDecoding(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		mode    DecodeMode
+		wantErr error
+	}{
+		"unknown delta property is left to the operation": {
+			data: `{"deltas":[{"patches":[],"updateCommitment":"c","extra":1}]}`,
+			mode: DecodeStrict,
+		},
+		"unknown envelope property": {
+			data:    `{"deltas":[],"extra":1}`,
+			mode:    DecodeStrict,
+			wantErr: ErrUnknownProperty,
+		},
+		"duplicate key inside a delta": {
+			data:    `{"deltas":[{"updateCommitment":"a","updateCommitment":"b"}]}`,
+			mode:    DecodeStrict,
+			wantErr: ErrDuplicateProperty,
+		},
+		"wrong delta type": {
+			data:    `{"deltas":[{"patches":"nope"}]}`,
+			mode:    DecodeStrict,
+			wantErr: ErrInvalidPropertyType,
+		},
+		"lenient accepts unknown envelope property": {
+			data: `{"deltas":[],"extra":1}`,
+			mode: DecodeLenient,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewChunkFile([]byte(test.data), WithChunkDecodeMode(test.mode))
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestProcessorStrictDecoding verifies that strict decoding is the processor
+// default, that its rejections are permanent (ErrMalformed) and keep their
+// sentinel, and that WithDecodeMode(DecodeLenient) opts out.
+func TestProcessorStrictDecoding(t *testing.T) {
+	tests := map[string]struct {
+		coreIndex string
+		opts      []SideTreeOption
+		wantErr   error
+	}{
+		"unknown property rejected by default": {
+			coreIndex: `{"operations":{},"unexpected":"value"}`,
+			wantErr:   ErrUnknownProperty,
+		},
+		"duplicate key rejected by default": {
+			coreIndex: `{"writerLockId":"a","writerLockId":"b"}`,
+			wantErr:   ErrDuplicateProperty,
+		},
+		"wrong type rejected by default": {
+			coreIndex: `{"operations":[]}`,
+			wantErr:   ErrInvalidPropertyType,
+		},
+		"lenient mode accepts unknown property": {
+			coreIndex: `{"operations":{},"unexpected":"value"}`,
+			opts:      []SideTreeOption{WithDecodeMode(DecodeLenient)},
+			wantErr:   nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cas := NewTestCAS()
+			cas.insertObject(coreIndexCID, []byte(test.coreIndex))
+
+			opts := append([]SideTreeOption{WithCAS(cas), WithPrefix("test")}, test.opts...)
+			p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, opts...)
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+
+			got := p.Process()
+			if test.wantErr == nil {
+				if got.Error != nil {
+					t.Fatalf("expected no error, got %v", got.Error)
+				}
+				return
+			}
+			if !errors.Is(got.Error, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, got.Error)
+			}
+			if !errors.Is(got.Error, ErrMalformed) {
+				t.Errorf("expected rejection to be ErrMalformed (permanent), got %v", got.Error)
+			}
+		})
+	}
+}
+
+// TestSideTreeForwardsDecodeMode verifies that a SideTree built WithDecodeMode
+// hands the mode to every per-anchor processor.
+func TestSideTreeForwardsDecodeMode(t *testing.T) {
+	cas := NewTestCAS()
+	b, err := json.Marshal(map[string]interface{}{"operations": map[string]interface{}{}, "unexpected": 1})
+	if err != nil {
+		t.Fatalf("failed to marshal core index: %v", err)
+	}
+	cas.insertObject(coreIndexCID, b)
+	op := operations.Anchor{Anchor: "1." + coreIndexCID}
+
+	strict := New(WithPrefix("test"), WithCAS(cas))
+	opMap, err := strict.ProcessOperations([]operations.Anchor{op}, nil)
+	if err != nil {
+		t.Fatalf("unexpected top-level error: %v", err)
+	}
+	if !errors.Is(opMap[op].Error, ErrUnknownProperty) {
+		t.Errorf("expected default SideTree to reject unknown property, got %v", opMap[op].Error)
+	}
+
+	lenient := New(WithPrefix("test"), WithCAS(cas), WithDecodeMode(DecodeLenient))
+	opMap, err = lenient.ProcessOperations([]operations.Anchor{op}, nil)
+	if err != nil {
+		t.Fatalf("unexpected top-level error: %v", err)
+	}
+	if opMap[op].Error != nil {
+		t.Errorf("expected lenient SideTree to accept unknown property, got %v", opMap[op].Error)
+	}
+}
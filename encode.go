@@ -0,0 +1,172 @@
+package sidetree
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+)
+
+// The Encode methods below write the canonical form of each Sidetree file, so
+// that the JSON of a batch is reproducible. Only the JSON is canonical: the
+// compressed file, and therefore its CAS URI, depends on the compressor (see
+// CompressFile).
+//
+//   - properties appear in the order the reference implementation writes them;
+//   - empty properties are omitted: an absent writerLockId or proof file URI,
+//     an operations object with no operations, an empty operation array. The
+//     reference reader rejects an empty URI or a null array where it accepts
+//     an absent property;
+//   - the JSON is compact, with no trailing newline, and '<', '>' and '&' are
+//     not escaped, as JSON.stringify writes them.
+//
+// A delta's patches are maps, so their properties are written in key order.
+// Every file an Encode method writes decodes with its constructor.
+
+// CoreIndexFile.Encode and ProvisionalIndexFile.Encode write the operation
+// references through these forms. The reference writes the suffix data
+// properties it knows in this order.
+type suffixDataWire struct {
+	DeltaHash          string `json:"deltaHash"`
+	RecoveryCommitment string `json:"recoveryCommitment"`
+	Type               string `json:"type,omitempty"`
+	AnchorOrigin       string `json:"anchorOrigin,omitempty"`
+}
+
+func suffixDataWireOf(s did.SuffixData) suffixDataWire {
+	return suffixDataWire{
+		DeltaHash:          s.DeltaHash,
+		RecoveryCommitment: s.RecoveryCommitment,
+		Type:               s.Type,
+		AnchorOrigin:       s.AnchorOrigin,
+	}
+}
+
+type createOperationWire struct {
+	SuffixData suffixDataWire `json:"suffixData"`
+}
+
+type coreOperationsWire struct {
+	Create     []createOperationWire `json:"create,omitempty"`
+	Recover    []Operation           `json:"recover,omitempty"`
+	Deactivate []Operation           `json:"deactivate,omitempty"`
+}
+
+type coreIndexWire struct {
+	WriterLockID        string              `json:"writerLockId,omitempty"`
+	ProvisionalIndexURI string              `json:"provisionalIndexFileUri,omitempty"`
+	CoreProofURI        string              `json:"coreProofFileUri,omitempty"`
+	Operations          *coreOperationsWire `json:"operations,omitempty"`
+}
+
+type coreProofWire struct {
+	Operations struct {
+		Recover    []SignedRecoverDataOp    `json:"recover,omitempty"`
+		Deactivate []SignedDeactivateDataOp `json:"deactivate,omitempty"`
+	} `json:"operations"`
+}
+
+type provisionalIndexWire struct {
+	ProvisionalProofURI string      `json:"provisionalProofFileUri,omitempty"`
+	Chunks              []ProvChunk `json:"chunks"`
+	Operations          *ProvOPS    `json:"operations,omitempty"`
+}
+
+type provisionalProofWire struct {
+	Operations struct {
+		Update []SignedUpdateDataOp `json:"update,omitempty"`
+	} `json:"operations"`
+}
+
+type chunkWire struct {
+	Deltas []did.Delta `json:"deltas"`
+}
+
+// Encode returns the canonical encoding of the core index file.
+func (c *CoreIndexFile) Encode() ([]byte, error) {
+	f := coreIndexWire{
+		WriterLockID:        c.WriterLockId,
+		ProvisionalIndexURI: c.ProvisionalIndexURI,
+		CoreProofURI:        c.CoreProofURI,
+	}
+	ops := c.Operations
+	if len(ops.Create)+len(ops.Recover)+len(ops.Deactivate) > 0 {
+		f.Operations = &coreOperationsWire{Recover: ops.Recover, Deactivate: ops.Deactivate}
+		for _, op := range ops.Create {
+			f.Operations.Create = append(f.Operations.Create, createOperationWire{SuffixData: suffixDataWireOf(op.SuffixData)})
+		}
+	}
+	return encodeFile(FileCoreIndex, f)
+}
+
+// Encode returns the canonical encoding of the core proof file.
+func (p *CoreProofFile) Encode() ([]byte, error) {
+	var f coreProofWire
+	f.Operations.Recover = p.Operations.Recover
+	f.Operations.Deactivate = p.Operations.Deactivate
+	return encodeFile(FileCoreProof, f)
+}
+
+// Encode returns the canonical encoding of the provisional index file.
+func (p *ProvisionalIndexFile) Encode() ([]byte, error) {
+	f := provisionalIndexWire{
+		ProvisionalProofURI: p.ProvisionalProofURI,
+		Chunks:              p.Chunks,
+	}
+	if f.Chunks == nil {
+		f.Chunks = []ProvChunk{}
+	}
+	if len(p.Operations.Update) > 0 {
+		f.Operations = &ProvOPS{Update: p.Operations.Update}
+	}
+	return encodeFile(FileProvisionalIndex, f)
+}
+
+// Encode returns the canonical encoding of the provisional proof file.
+func (p *ProvisionalProofFile) Encode() ([]byte, error) {
+	var f provisionalProofWire
+	f.Operations.Update = p.Operations.Update
+	return encodeFile(FileProvisionalProof, f)
+}
+
+// Encode returns the canonical encoding of the chunk file.
+func (c *ChunkFile) Encode() ([]byte, error) {
+	f := chunkWire{Deltas: c.Deltas}
+	if f.Deltas == nil {
+		f.Deltas = []did.Delta{}
+	}
+	return encodeFile(FileChunk, f)
+}
+
+// encodeFile writes v as compact JSON without HTML escaping.
+func encodeFile(file FileType, v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", file.description(), err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// CompressFile gzips an encoded Sidetree file the way a writer stores it: at
+// the default compression level, with an empty header (no name, comment or
+// modification time), so this package always compresses the same file to the
+// same bytes. The deflate stream is Go's, not zlib's: the reference writer
+// compresses the same JSON to different bytes, and so to a different CID.
+func CompressFile(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,159 @@
+package sidetree
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	// Each decode function returns the exported part of the decoded file.
+	decodeCoreIndex := func(data []byte) (fileEncoder, error) { return NewCoreIndexFile(data) }
+	decodeCoreProof := func(data []byte) (fileEncoder, error) { return NewCoreProofFile(data) }
+	decodeProvisionalIndex := func(data []byte) (fileEncoder, error) { return NewProvisionalIndexFile(data) }
+	decodeProvisionalProof := func(data []byte) (fileEncoder, error) { return NewProvisionalProofFile(data) }
+	decodeChunk := func(data []byte) (fileEncoder, error) {
+		c, err := NewChunkFile(data)
+		if err != nil {
+			return nil, err
+		}
+		return &ChunkFile{Deltas: c.Deltas}, nil
+	}
+
+	tests := map[string]struct {
+		file   fileEncoder
+		decode func([]byte) (fileEncoder, error)
+		want   string
+	}{
+		"core index": {
+			file: &CoreIndexFile{
+				WriterLockId:        "lock",
+				ProvisionalIndexURI: "provisional-index",
+				CoreProofURI:        "core-proof",
+				Operations: CoreOperations{
+					Create:     []CreateOperation{{SuffixData: did.SuffixData{Type: "t", DeltaHash: "dh", RecoveryCommitment: "rc"}}},
+					Recover:    []Operation{{DIDSuffix: "recover-did", RevealValue: "rv"}},
+					Deactivate: []Operation{{DIDSuffix: "deactivate-did", RevealValue: "dv"}},
+				},
+			},
+			decode: decodeCoreIndex,
+			want: `{"writerLockId":"lock","provisionalIndexFileUri":"provisional-index","coreProofFileUri":"core-proof",` +
+				`"operations":{"create":[{"suffixData":{"deltaHash":"dh","recoveryCommitment":"rc","type":"t"}}],` +
+				`"recover":[{"didSuffix":"recover-did","revealValue":"rv"}],"deactivate":[{"didSuffix":"deactivate-did","revealValue":"dv"}]}}`,
+		},
+		"core index with creates only": {
+			file: &CoreIndexFile{
+				ProvisionalIndexURI: "provisional-index",
+				Operations:          CoreOperations{Create: []CreateOperation{{SuffixData: did.SuffixData{DeltaHash: "dh", RecoveryCommitment: "rc"}}}},
+			},
+			decode: decodeCoreIndex,
+			want:   `{"provisionalIndexFileUri":"provisional-index","operations":{"create":[{"suffixData":{"deltaHash":"dh","recoveryCommitment":"rc"}}]}}`,
+		},
+		"empty core index": {
+			file:   &CoreIndexFile{},
+			decode: decodeCoreIndex,
+			want:   `{}`,
+		},
+		"core proof": {
+			file: &CoreProofFile{Operations: CoreProofOperations{
+				Recover:    []SignedRecoverDataOp{{SignedData: "recover-jws"}},
+				Deactivate: []SignedDeactivateDataOp{{SignedData: "deactivate-jws"}},
+			}},
+			decode: decodeCoreProof,
+			want:   `{"operations":{"recover":[{"signedData":"recover-jws"}],"deactivate":[{"signedData":"deactivate-jws"}]}}`,
+		},
+		"core proof with deactivates only": {
+			file:   &CoreProofFile{Operations: CoreProofOperations{Deactivate: []SignedDeactivateDataOp{{SignedData: "deactivate-jws"}}}},
+			decode: decodeCoreProof,
+			want:   `{"operations":{"deactivate":[{"signedData":"deactivate-jws"}]}}`,
+		},
+		"provisional index": {
+			file: &ProvisionalIndexFile{
+				ProvisionalProofURI: "provisional-proof",
+				Operations:          ProvOPS{Update: []Operation{{DIDSuffix: "update-did", RevealValue: "uv"}}},
+				Chunks:              []ProvChunk{{ChunkFileURI: "chunk"}},
+			},
+			decode: decodeProvisionalIndex,
+			want:   `{"provisionalProofFileUri":"provisional-proof","chunks":[{"chunkFileUri":"chunk"}],"operations":{"update":[{"didSuffix":"update-did","revealValue":"uv"}]}}`,
+		},
+		"provisional index without updates": {
+			file:   &ProvisionalIndexFile{Chunks: []ProvChunk{{ChunkFileURI: "chunk"}}},
+			decode: decodeProvisionalIndex,
+			want:   `{"chunks":[{"chunkFileUri":"chunk"}]}`,
+		},
+		"provisional proof": {
+			file:   &ProvisionalProofFile{Operations: ProvProofOperations{Update: []SignedUpdateDataOp{{SignedData: "update-jws"}}}},
+			decode: decodeProvisionalProof,
+			want:   `{"operations":{"update":[{"signedData":"update-jws"}]}}`,
+		},
+		"chunk": {
+			file: &ChunkFile{Deltas: []did.Delta{{
+				Patches:          []map[string]interface{}{{"services": []interface{}{}, "action": "add-services"}},
+				UpdateCommitment: "<commitment&>",
+			}}},
+			decode: decodeChunk,
+			want:   `{"deltas":[{"patches":[{"action":"add-services","services":[]}],"updateCommitment":"<commitment&>"}]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := test.file.Encode()
+			if err != nil {
+				t.Fatalf("expected no error encoding, got %v", err)
+			}
+			if string(data) != test.want {
+				t.Fatalf("expected\n%s\ngot\n%s", test.want, data)
+			}
+
+			decoded, err := test.decode(data)
+			if err != nil {
+				t.Fatalf("expected the encoding to decode strictly, got %v", err)
+			}
+			if !reflect.DeepEqual(decoded, test.file) {
+				t.Errorf("expected %+v to round-trip, got %+v", test.file, decoded)
+			}
+			again, err := decoded.Encode()
+			if err != nil {
+				t.Fatalf("expected no error re-encoding, got %v", err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("expected the re-encoding to be identical, got\n%s", again)
+			}
+		})
+	}
+}
+
+func TestCompressFile(t *testing.T) {
+	data := []byte(`{"deltas":[]}`)
+	first, err := CompressFile(data)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, err := CompressFile(data)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("expected compressing the same file twice to give the same bytes")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("expected a gzip stream, got %v", err)
+	}
+	if zr.Name != "" || zr.Comment != "" || !zr.ModTime.IsZero() {
+		t.Errorf("expected an empty gzip header, got %+v", zr.Header)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("expected %s, got %s", data, got)
+	}
+}
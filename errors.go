@@ -10,6 +10,13 @@ var (
 	ErrDuplicateOperation = fmt.Errorf("duplicate operation")
 	ErrNoCoreProof        = fmt.Errorf("core proof uri is empty")
 
+	// ErrMissingPrerequisite: a Sidetree file was processed without the
+	// context the files before it set up, such as a core proof file with no
+	// core index file, or a chunk file given a mapping array that names
+	// operations it was not given. The processor always processes the files in
+	// order, so only callers driving the file types directly can see it.
+	ErrMissingPrerequisite = fmt.Errorf("sidetree file processed without its prerequisite files")
+
 	// Per-anchor operation-count limits (Sidetree protocol rule). A
 	// spec-compliant ION node rejects the ENTIRE anchored batch — permanently,
 	// never retried — when an anchor's operation count violates these, so they
@@ -17,11 +24,14 @@ var (
 	// docs/plans/2026-06-04-001-feat-ion-value-locking-protocol-rules-plan.md.
 
 	// ErrInvalidOperationCount: the anchor string declares a non-positive or
-	// unparseable operation count. AnchorString.Operations() returns 0 for both a
-	// literal "0" and a non-numeric count, and a negative value parses through, so
-	// any count < 1 is treated as a malformed anchor (ION rejects it at parse time).
+	// unparseable operation count: anything but a positive decimal without a
+	// sign or leading zeros (see ParseAnchorString). ION rejects it at parse time.
 	ErrInvalidOperationCount = fmt.Errorf("anchor declares a non-positive or unparseable operation count")
 
+	// ErrInvalidAnchorString: the anchor string is not "<count>.<CID>" (see
+	// ParseAnchorString). An invalid count wraps ErrInvalidOperationCount too.
+	ErrInvalidAnchorString = fmt.Errorf("invalid anchor string")
+
 	// ErrTooManyOperations: the anchor declares more operations than
 	// MaxOperationsPerBatch (the absolute ceiling; no value lock can exceed it).
 	ErrTooManyOperations = fmt.Errorf("anchor operation count exceeds maxOperationsPerBatch")
@@ -58,13 +68,21 @@ var (
 	ErrWriterLockIDTooLong = fmt.Errorf("writerLockId exceeds maxWriterLockIdInBytes")
 	ErrDeltaTooLarge       = fmt.Errorf("operation delta exceeds maxDeltaSizeInBytes")
 
+	// Optional parse-time encoding checks (see WithMultihashValidation). A DID
+	// suffix or reveal value that is not a base64url-encoded SHA-256 multihash
+	// can never resolve, and an unvalidated suffix would take part in the
+	// batch's duplicate-suffix detection as an arbitrary string.
+	ErrInvalidDIDSuffix   = fmt.Errorf("DID suffix is not a base64url-encoded SHA-256 multihash")
+	ErrInvalidRevealValue = fmt.Errorf("reveal value is not a base64url-encoded SHA-256 multihash")
+
 	// ErrContentUnavailable marks a Sidetree-file fetch that failed because the
 	// CAS could not return the content (IPFS timeout, not-found, peer
 	// unreachable). The content may be published or become reachable later, so
 	// the anchored operation is "not-yet-applied" rather than invalid — callers
 	// should RECORD the anchor and RETRY. This is the failure mode late
 	// publishing depends on (an anchor whose content surfaces later splices in
-	// at its original txnum and can retroactively invalidate later operations).
+	// at its original txnum and can retroactively invalidate later operations;
+	// see State.Apply).
 	ErrContentUnavailable = errors.New("content unavailable")
 
 	// ErrMalformed marks content that WAS retrieved but is structurally or
@@ -74,6 +92,11 @@ var (
 	ErrMalformed = errors.New("malformed content")
 )
 
+// missingPrerequisite reports that file was processed without missing.
+func missingPrerequisite(file, missing string) error {
+	return fmt.Errorf("%w: %s needs %s", ErrMissingPrerequisite, file, missing)
+}
+
 // classifyFetch tags a CAS Get failure with its retryability class. A fetch
 // failure is content-unavailable (retryable) by default: the content may
 // publish or the CAS may reconnect later. A CAS that can prove the bytes are
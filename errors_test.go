@@ -36,23 +36,23 @@ func TestProcessErrorClassification(t *testing.T) {
 		wantUnderlying error // optional: a specific sentinel that must remain matchable
 	}{
 		"missing core index is unavailable": {
-			anchor:    operations.Anchor{Anchor: "1.missing"},
+			anchor:    operations.Anchor{Anchor: "1." + missingCID},
 			cas:       NewTestCAS(),
 			wantClass: ErrContentUnavailable,
 			notClass:  ErrMalformed,
 		},
 		"unparseable core index is malformed": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			cas: func() CAS {
 				c := NewTestCAS()
-				c.insertObject("abc", []byte("not json"))
+				c.insertObject(coreIndexCID, []byte("not json"))
 				return c
 			}(),
 			wantClass: ErrMalformed,
 			notClass:  ErrContentUnavailable,
 		},
 		"process validation error is malformed and keeps its sentinel": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			cas: func() CAS {
 				c := NewTestCAS()
 				// A recover op with no core-proof URI -> coreIndexFile.Process
@@ -61,7 +61,7 @@ func TestProcessErrorClassification(t *testing.T) {
 					Recover: []Operation{{DIDSuffix: "did:abc:123", RevealValue: "r"}},
 				}}
 				b, _ := json.Marshal(ci)
-				c.insertObject("abc", b)
+				c.insertObject(coreIndexCID, b)
 				return c
 			}(),
 			wantClass:      ErrMalformed,
@@ -69,19 +69,19 @@ func TestProcessErrorClassification(t *testing.T) {
 			wantUnderlying: ErrNoCoreProof,
 		},
 		"missing provisional index is unavailable": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			cas: func() CAS {
 				c := NewTestCAS()
 				ci := CoreIndexFile{ProvisionalIndexURI: "gone", Operations: CoreOperations{}}
 				b, _ := json.Marshal(ci)
-				c.insertObject("abc", b)
+				c.insertObject(coreIndexCID, b)
 				return c
 			}(),
 			wantClass: ErrContentUnavailable,
 			notClass:  ErrMalformed,
 		},
 		"CAS-signalled corruption stays malformed (no retry)": {
-			anchor:    operations.Anchor{Anchor: "1.whatever"},
+			anchor:    operations.Anchor{Anchor: "1." + missingCID},
 			cas:       malformedCAS{},
 			wantClass: ErrMalformed,
 			notClass:  ErrContentUnavailable,
@@ -0,0 +1,91 @@
+package sidetree
+
+// FileOption configures how a Sidetree file constructor decodes a file and
+// how its Process validates it. The defaults decode strictly, check no
+// multihashes and leave signed data unverified.
+type FileOption func(o *fileOptions)
+
+type fileOptions struct {
+	decodeMode       DecodeMode
+	multihashChecks  MultihashCheck
+	verifySignatures bool
+}
+
+// WithFileDecodeMode selects how the file JSON is decoded. The default is
+// DecodeStrict.
+func WithFileDecodeMode(mode DecodeMode) FileOption {
+	return func(o *fileOptions) {
+		o.decodeMode = mode
+	}
+}
+
+// WithFileMultihashValidation validates the selected operation-reference
+// fields of an index file as base64url-encoded SHA-256 multihashes when it is
+// processed.
+func WithFileMultihashValidation(checks MultihashCheck) FileOption {
+	return func(o *fileOptions) {
+		o.multihashChecks = checks
+	}
+}
+
+// WithFileSignatureVerification verifies the compact-JWS signed data of every
+// operation in a proof file when it is processed. An operation that fails is
+// reported by InvalidOps rather than failing the file.
+func WithFileSignatureVerification(enabled bool) FileOption {
+	return func(o *fileOptions) {
+		o.verifySignatures = enabled
+	}
+}
+
+func newFileOptions(opts []FileOption) fileOptions {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// signedDataResults collects the outcome of verifying the signed data of the
+// operations in a proof file, keyed by DID suffix.
+type signedDataResults struct {
+	signedDeltaHashes map[string]string
+	invalidOps        map[string]error
+}
+
+// record stores the deltaHash a verified recover or update signed for id, or
+// the reason its signed data did not verify.
+func (r *signedDataResults) record(id string, deltaHash string, err error) {
+	if err != nil {
+		r.flag(id, err)
+		return
+	}
+	if r.signedDeltaHashes == nil {
+		r.signedDeltaHashes = map[string]string{}
+	}
+	r.signedDeltaHashes[id] = deltaHash
+}
+
+// flag records that the operation for id is invalid on its own. The first
+// reason recorded for an id wins.
+func (r *signedDataResults) flag(id string, err error) {
+	if r.invalidOps == nil {
+		r.invalidOps = map[string]error{}
+	}
+	if _, ok := r.invalidOps[id]; !ok {
+		r.invalidOps[id] = err
+	}
+}
+
+// SignedDeltaHashes returns the deltaHash each verified recover or update
+// operation signed, keyed by DID suffix, to be compared with the delta the
+// chunk file maps to it. It is empty unless signed data is verified.
+func (r *signedDataResults) SignedDeltaHashes() map[string]string {
+	return r.signedDeltaHashes
+}
+
+// InvalidOps returns the reason each operation whose signed data did not
+// verify is invalid, keyed by DID suffix. It is empty unless signed data is
+// verified.
+func (r *signedDataResults) InvalidOps() map[string]error {
+	return r.invalidOps
+}
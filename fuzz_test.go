@@ -0,0 +1,336 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// The fuzz targets feed attacker-controlled bytes to the processor the way a
+// CAS would. The per-file targets replace one file of a valid batch, so that
+// the file's constructor and Process run with the context the files before it
+// set up; FuzzProcessor replaces all of them and the anchor string. The
+// FuzzNew* targets give one file to its constructor and Process directly,
+// against the other files of a valid batch. Every target checks that
+// processing does not panic and that the number of allocations it makes is
+// bounded by the input size; the processor targets also check that a batch
+// error is classified as ErrMalformed or ErrContentUnavailable.
+//
+// Run one with, for example:
+//
+//	go test -run '^$' -fuzz FuzzCoreIndexFile
+
+// The file URIs of the accepted/all-operation-types conformance vector. The
+// fuzzed batches store each file under the URI of the same file of that
+// vector, whichever vector it was taken from.
+const (
+	fuzzCoreIndexURI        = "bafkreifssjckpe5btogibumgrkvb3imxi2eky6pfzkeibp7gd7l3txpnry"
+	fuzzCoreProofURI        = "bafkreifdh6ud6awqsmdvmzuq7crm67qpudgg5xcfi43umvqvffzhklgu5a"
+	fuzzProvisionalIndexURI = "bafkreifk3sowr35phbtle2jtdozevyfmhlxxl4iju7ywnfkjjexozelj5m"
+	fuzzProvisionalProofURI = "bafkreifhcxiz7movfkarmblvgejord6luvtvk5ususxjjupt5pemwvkkpq"
+	fuzzChunkURI            = "bafkreihf2zzp3ovbv5zqwdcbov5wns2tz3ijnhqbfpnbnj22a2jhbnlpce"
+)
+
+// fuzzAnchor anchors the all-operation-types vector the per-file targets
+// start from.
+const fuzzAnchor = "4." + fuzzCoreIndexURI
+
+// fuzzAllocsBase and fuzzAllocsPerByte bound the allocations one run may make,
+// as testing.AllocsPerRun counts them: a fixed overhead plus a multiple of the
+// input size.
+const (
+	fuzzAllocsBase    = 5000
+	fuzzAllocsPerByte = 4
+)
+
+// fuzzVectors returns the files of every conformance vector, keyed by vector
+// then by the fuzz URI of the same file, as seeds. A vector's files are found
+// by following the URIs from its anchor string.
+func fuzzVectors(f *testing.F) map[string]map[string][]byte {
+	f.Helper()
+	dirs, err := filepath.Glob(filepath.Join("testdata", "conformance", "*", "*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	vectors := map[string]map[string][]byte{}
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "vector.json"))
+		if err != nil {
+			f.Fatal(err)
+		}
+		var vector conformanceVector
+		if err := json.Unmarshal(data, &vector); err != nil {
+			f.Fatal(err)
+		}
+
+		files := map[string][]byte{}
+		read := func(fuzzURI, uri string) []byte {
+			if uri == "" || !filepath.IsLocal(uri) {
+				return nil
+			}
+			data, err := os.ReadFile(filepath.Join(dir, uri))
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				f.Fatal(err)
+			}
+			files[fuzzURI] = data
+			return data
+		}
+
+		// The references are read leniently: a vector's files may be
+		// malformed on purpose.
+		var coreIndex struct {
+			ProvisionalIndexFileURI string `json:"provisionalIndexFileUri"`
+			CoreProofFileURI        string `json:"coreProofFileUri"`
+		}
+		var provisionalIndex struct {
+			ProvisionalProofFileURI string `json:"provisionalProofFileUri"`
+			Chunks                  []struct {
+				ChunkFileURI string `json:"chunkFileUri"`
+			} `json:"chunks"`
+		}
+		_, coreIndexURI, _ := strings.Cut(vector.Anchor, ".")
+		_ = json.Unmarshal(read(fuzzCoreIndexURI, coreIndexURI), &coreIndex)
+		read(fuzzCoreProofURI, coreIndex.CoreProofFileURI)
+		_ = json.Unmarshal(read(fuzzProvisionalIndexURI, coreIndex.ProvisionalIndexFileURI), &provisionalIndex)
+		read(fuzzProvisionalProofURI, provisionalIndex.ProvisionalProofFileURI)
+		if len(provisionalIndex.Chunks) > 0 {
+			read(fuzzChunkURI, provisionalIndex.Chunks[0].ChunkFileURI)
+		}
+		name, _ := filepath.Rel(filepath.Join("testdata", "conformance"), dir)
+		vectors[filepath.ToSlash(name)] = files
+	}
+	if _, ok := vectors["accepted/all-operation-types"]; !ok {
+		f.Fatal("missing the accepted/all-operation-types conformance vector")
+	}
+	return vectors
+}
+
+// fuzzFile fuzzes the file stored under uri in an otherwise valid batch.
+func fuzzFile(f *testing.F, uri string) {
+	vectors := fuzzVectors(f)
+	for _, name := range sortedKeys(vectors) {
+		if data, ok := vectors[name][uri]; ok {
+			f.Add(data)
+		}
+	}
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`[]`))
+
+	base := vectors["accepted/all-operation-types"]
+	f.Fuzz(func(t *testing.T, data []byte) {
+		files := map[string][]byte{}
+		for u, content := range base {
+			files[u] = content
+		}
+		files[uri] = data
+		processFuzzed(t, fuzzAnchor, files)
+	})
+}
+
+func FuzzCoreIndexFile(f *testing.F) {
+	fuzzFile(f, fuzzCoreIndexURI)
+}
+
+func FuzzCoreProofFile(f *testing.F) {
+	fuzzFile(f, fuzzCoreProofURI)
+}
+
+func FuzzProvisionalIndexFile(f *testing.F) {
+	fuzzFile(f, fuzzProvisionalIndexURI)
+}
+
+func FuzzProvisionalProofFile(f *testing.F) {
+	fuzzFile(f, fuzzProvisionalProofURI)
+}
+
+func FuzzChunkFile(f *testing.F) {
+	fuzzFile(f, fuzzChunkURI)
+}
+
+// FuzzProcessor fuzzes the anchor string and every file of the batch. An
+// empty file is missing from the CAS.
+func FuzzProcessor(f *testing.F) {
+	vectors := fuzzVectors(f)
+	for _, name := range sortedKeys(vectors) {
+		files := vectors[name]
+		f.Add(fuzzAnchor, files[fuzzCoreIndexURI], files[fuzzCoreProofURI], files[fuzzProvisionalIndexURI], files[fuzzProvisionalProofURI], files[fuzzChunkURI])
+	}
+	f.Add("1."+fuzzCoreIndexURI, []byte(nil), []byte(nil), []byte(nil), []byte(nil), []byte(nil))
+
+	f.Fuzz(func(t *testing.T, anchor string, coreIndex, coreProof, provisionalIndex, provisionalProof, chunk []byte) {
+		files := map[string][]byte{}
+		for uri, data := range map[string][]byte{
+			fuzzCoreIndexURI:        coreIndex,
+			fuzzCoreProofURI:        coreProof,
+			fuzzProvisionalIndexURI: provisionalIndex,
+			fuzzProvisionalProofURI: provisionalProof,
+			fuzzChunkURI:            chunk,
+		} {
+			if len(data) > 0 {
+				files[uri] = data
+			}
+		}
+		processFuzzed(t, anchor, files)
+	})
+}
+
+// processFuzzed processes anchor against a CAS holding files, with every
+// optional check the processor has enabled, and checks the fuzz invariants.
+func processFuzzed(t *testing.T, anchor string, files map[string][]byte) {
+	size := len(anchor)
+	for _, data := range files {
+		size += len(data)
+	}
+
+	var got ProcessedOperations
+	checkAllocs(t, size, func() {
+		cas := NewTestCAS()
+		for uri, data := range files {
+			cas.insertObject(uri, data)
+		}
+		p, err := Processor(operations.Anchor{Anchor: operations.AnchorString(anchor)},
+			WithCAS(cas),
+			WithPrefix("ion"),
+			WithMultihashValidation(CheckReferenceMultihashes),
+			WithSignatureVerification(true),
+		)
+		if err != nil {
+			// An anchor string without a core index URI never reaches a file.
+			got = ProcessedOperations{}
+			return
+		}
+		got = p.Process()
+	})
+
+	if got.Error != nil && !errors.Is(got.Error, ErrMalformed) && !errors.Is(got.Error, ErrContentUnavailable) {
+		t.Errorf("unclassified error: %v", got.Error)
+	}
+}
+
+// checkAllocs fails t if run, given size bytes of input, makes more
+// allocations than the fuzz bound.
+func checkAllocs(t *testing.T, size int, run func()) {
+	t.Helper()
+	if allocs, limit := testing.AllocsPerRun(1, run), float64(fuzzAllocsBase+fuzzAllocsPerByte*size); allocs > limit {
+		t.Errorf("made %.0f allocations for %d bytes of input (limit %.0f)", allocs, size, limit)
+	}
+}
+
+// fuzzFileOptions enables every optional check of the file constructors.
+var fuzzFileOptions = []FileOption{
+	WithFileMultihashValidation(CheckReferenceMultihashes),
+	WithFileSignatureVerification(true),
+}
+
+// fuzzBatch is the accepted/all-operation-types batch, each file decoded and
+// processed against the files before it, for a FuzzNew* target to process its
+// file against. Processing a file fills in the operations of the files before
+// it, so each run builds its own.
+type fuzzBatch struct {
+	coreIndex        *CoreIndexFile
+	coreProof        *CoreProofFile
+	provisionalIndex *ProvisionalIndexFile
+	provisionalProof *ProvisionalProofFile
+}
+
+func newFuzzBatch(t *testing.T, files map[string][]byte) fuzzBatch {
+	t.Helper()
+	var b fuzzBatch
+	var err error
+	if b.coreIndex, err = NewCoreIndexFile(files[fuzzCoreIndexURI], fuzzFileOptions...); err == nil {
+		err = b.coreIndex.Process()
+	}
+	if err == nil {
+		if b.coreProof, err = NewCoreProofFile(files[fuzzCoreProofURI], fuzzFileOptions...); err == nil {
+			err = b.coreProof.Process(b.coreIndex)
+		}
+	}
+	if err == nil {
+		if b.provisionalIndex, err = NewProvisionalIndexFile(files[fuzzProvisionalIndexURI], fuzzFileOptions...); err == nil {
+			err = b.provisionalIndex.Process(b.coreIndex)
+		}
+	}
+	if err == nil {
+		if b.provisionalProof, err = NewProvisionalProofFile(files[fuzzProvisionalProofURI], fuzzFileOptions...); err == nil {
+			err = b.provisionalProof.Process(b.provisionalIndex)
+		}
+	}
+	if err != nil {
+		t.Fatalf("the all-operation-types batch does not process: %v", err)
+	}
+	return b
+}
+
+// fuzzDirect fuzzes the file stored under uri given to its constructor and
+// Process directly. process decodes and processes data against batch.
+func fuzzDirect(f *testing.F, uri string, process func(batch fuzzBatch, data []byte)) {
+	vectors := fuzzVectors(f)
+	for _, name := range sortedKeys(vectors) {
+		if data, ok := vectors[name][uri]; ok {
+			f.Add(data)
+		}
+	}
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`[]`))
+
+	base := vectors["accepted/all-operation-types"]
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			process(newFuzzBatch(t, base), data)
+		})
+	})
+}
+
+func FuzzNewCoreIndexFile(f *testing.F) {
+	fuzzDirect(f, fuzzCoreIndexURI, func(_ fuzzBatch, data []byte) {
+		if c, err := NewCoreIndexFile(data, fuzzFileOptions...); err == nil {
+			_ = c.Process()
+		}
+	})
+}
+
+func FuzzNewCoreProofFile(f *testing.F) {
+	fuzzDirect(f, fuzzCoreProofURI, func(batch fuzzBatch, data []byte) {
+		if p, err := NewCoreProofFile(data, fuzzFileOptions...); err == nil {
+			_ = p.Process(batch.coreIndex)
+		}
+	})
+}
+
+func FuzzNewProvisionalIndexFile(f *testing.F) {
+	fuzzDirect(f, fuzzProvisionalIndexURI, func(batch fuzzBatch, data []byte) {
+		if p, err := NewProvisionalIndexFile(data, fuzzFileOptions...); err == nil {
+			_ = p.Process(batch.coreIndex)
+		}
+	})
+}
+
+func FuzzNewProvisionalProofFile(f *testing.F) {
+	fuzzDirect(f, fuzzProvisionalProofURI, func(batch fuzzBatch, data []byte) {
+		if p, err := NewProvisionalProofFile(data, fuzzFileOptions...); err == nil {
+			_ = p.Process(batch.provisionalIndex)
+		}
+	})
+}
+
+func FuzzNewChunkFile(f *testing.F) {
+	fuzzDirect(f, fuzzChunkURI, func(batch fuzzBatch, data []byte) {
+		c, err := NewChunkFile(data,
+			WithMappingArrays(batch.coreIndex.CreateMappingArray(), batch.coreIndex.RecoverMappingArray(), batch.provisionalIndex.UpdateMappingArray()),
+			WithOperations(batch.coreIndex.CreateOps(), batch.coreProof.RecoverOps(), batch.provisionalProof.UpdateOps()),
+		)
+		if err == nil {
+			_ = c.Process()
+		}
+	})
+}
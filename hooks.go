@@ -0,0 +1,124 @@
+package sidetree
+
+import (
+	"sort"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// Hooks observes the lifecycle of a batch. Every callback receives the anchor
+// being processed, so one Hooks can be shared by all the processors a SideTree
+// creates. Callbacks run synchronously on the goroutine calling Process and
+// must not retain or modify processor state; a slow callback slows processing.
+//
+// Embed NopHooks to implement only the callbacks you need.
+type Hooks interface {
+	// OnAnchorStart is called when Process starts, before any file is fetched.
+	OnAnchorStart(anchor operations.Anchor)
+	// OnFileFetched is called for every Sidetree file the CAS returned, with
+	// its (decompressed) size, before the file is parsed.
+	OnFileFetched(anchor operations.Anchor, file FileType, uri string, size int)
+	// OnBatchRejected is called when the whole batch is rejected, with the
+	// classified error also returned in ProcessedOperations.Error.
+	OnBatchRejected(anchor operations.Anchor, err error)
+	// OnOperationResolved is called for every valid operation of a processed
+	// batch, after DID filtering: creates, then recovers, updates and
+	// deactivates, each in DID suffix order.
+	OnOperationResolved(anchor operations.Anchor, opType OperationType, suffix string)
+}
+
+// DIDChangeHooks observes the DIDs a State resolves. A State calls the hooks
+// registered WithDIDChangeHooks synchronously on the goroutine calling
+// State.Apply, after the state is updated.
+type DIDChangeHooks interface {
+	// OnDIDChanged is called when applying anchor changed the resolution
+	// result of the DID with suffix, in DID suffix order. A late anchor can
+	// change DIDs through operations it invalidates. State.Resolve returns
+	// the new result.
+	OnDIDChanged(anchor operations.Anchor, suffix string)
+}
+
+// NopHooks implements Hooks with callbacks that do nothing.
+type NopHooks struct{}
+
+func (NopHooks) OnAnchorStart(operations.Anchor)                              {}
+func (NopHooks) OnFileFetched(operations.Anchor, FileType, string, int)       {}
+func (NopHooks) OnBatchRejected(operations.Anchor, error)                     {}
+func (NopHooks) OnOperationResolved(operations.Anchor, OperationType, string) {}
+
+// WithHooks registers hooks to be called while batches are processed. It may
+// be given more than once; hooks are called in registration order.
+func WithHooks(hooks ...Hooks) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.hooks = append(t.hooks, hooks...)
+		case *OperationsProcessor:
+			t.hooks = append(t.hooks, hooks...)
+		}
+	}
+}
+
+// WithDIDChangeHooks registers hooks a State calls for every DID an applied
+// anchor changes. It may be given more than once; hooks are called in
+// registration order.
+func WithDIDChangeHooks(hooks ...DIDChangeHooks) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *State:
+			t.hooks = append(t.hooks, hooks...)
+		}
+	}
+}
+
+func (d *OperationsProcessor) hookAnchorStart() {
+	for _, h := range d.hooks {
+		h.OnAnchorStart(d.op)
+	}
+}
+
+func (d *OperationsProcessor) hookFileFetched(file FileType, uri string, size int) {
+	for _, h := range d.hooks {
+		h.OnFileFetched(d.op, file, uri, size)
+	}
+}
+
+func (d *OperationsProcessor) hookBatchRejected(err error) {
+	for _, h := range d.hooks {
+		h.OnBatchRejected(d.op, err)
+	}
+}
+
+// hookOperationsResolved calls OnOperationResolved for every operation of
+// the processed batch result ops.
+func (d *OperationsProcessor) hookOperationsResolved(ops ProcessedOperations) {
+	if len(d.hooks) == 0 {
+		return
+	}
+
+	resolved := []struct {
+		opType   OperationType
+		suffixes []string
+	}{
+		{OperationCreate, sortedKeys(ops.CreateOps)},
+		{OperationRecover, sortedKeys(ops.RecoverOps)},
+		{OperationUpdate, sortedKeys(ops.UpdateOps)},
+		{OperationDeactivate, sortedKeys(ops.DeactivateOps)},
+	}
+	for _, r := range resolved {
+		for _, suffix := range r.suffixes {
+			for _, h := range d.hooks {
+				h.OnOperationResolved(d.op, r.opType, suffix)
+			}
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
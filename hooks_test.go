@@ -0,0 +1,97 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// recordingHooks records every callback as a string, in call order.
+type recordingHooks struct {
+	events []string
+	errs   []error
+}
+
+func (r *recordingHooks) OnAnchorStart(anchor operations.Anchor) {
+	r.events = append(r.events, fmt.Sprintf("start %s", anchor.Anchor))
+}
+
+func (r *recordingHooks) OnFileFetched(anchor operations.Anchor, file FileType, uri string, size int) {
+	r.events = append(r.events, fmt.Sprintf("fetched %s %s %s", anchor.Anchor, file, uri))
+}
+
+func (r *recordingHooks) OnBatchRejected(anchor operations.Anchor, err error) {
+	r.events = append(r.events, fmt.Sprintf("rejected %s", anchor.Anchor))
+	r.errs = append(r.errs, err)
+}
+
+func (r *recordingHooks) OnOperationResolved(anchor operations.Anchor, opType OperationType, suffix string) {
+	r.events = append(r.events, fmt.Sprintf("resolved %s %s %s", anchor.Anchor, opType, suffix))
+}
+
+func (r *recordingHooks) OnDIDChanged(anchor operations.Anchor, suffix string) {
+	r.events = append(r.events, fmt.Sprintf("changed %s %s", anchor.Anchor, suffix))
+}
+
+// startOnly counts anchors, relying on NopHooks for the other callbacks.
+type startOnly struct {
+	NopHooks
+	starts int
+}
+
+func (s *startOnly) OnAnchorStart(operations.Anchor) {
+	s.starts++
+}
+
+func TestProcessorHooks(t *testing.T) {
+	cas := NewTestCAS()
+	b, err := json.Marshal(CoreIndexFile{
+		CoreProofURI: "core-proof-uri",
+		Operations: CoreOperations{Deactivate: []Operation{
+			{DIDSuffix: "did-b"},
+			{DIDSuffix: "did-a"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal core index: %v", err)
+	}
+	cas.insertObject(coreIndexCID, b)
+	b, err = json.Marshal(CoreProofFile{Operations: CoreProofOperations{
+		Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}, {SignedData: "signed-data"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal core proof: %v", err)
+	}
+	cas.insertObject("core-proof-uri", b)
+
+	hooks := &recordingHooks{}
+	counter := &startOnly{}
+	s := New(WithPrefix("test"), WithCAS(cas), WithHooks(hooks), WithHooks(counter))
+	anchors := []operations.Anchor{{Anchor: "2." + coreIndexCID}, {Anchor: "1." + missingCID}}
+	if _, err := s.ProcessOperations(anchors, nil); err != nil {
+		t.Fatalf("unexpected top-level error: %v", err)
+	}
+
+	want := []string{
+		"start 2." + coreIndexCID,
+		"fetched 2." + coreIndexCID + " core_index " + coreIndexCID,
+		"fetched 2." + coreIndexCID + " core_proof core-proof-uri",
+		"resolved 2." + coreIndexCID + " deactivate did-a",
+		"resolved 2." + coreIndexCID + " deactivate did-b",
+		"start 1." + missingCID,
+		"rejected 1." + missingCID,
+	}
+	if !reflect.DeepEqual(hooks.events, want) {
+		t.Errorf("expected events\n%v\ngot\n%v", want, hooks.events)
+	}
+	if len(hooks.errs) != 1 || !errors.Is(hooks.errs[0], ErrContentUnavailable) {
+		t.Errorf("expected the rejection to carry the classified error, got %v", hooks.errs)
+	}
+	if counter.starts != 2 {
+		t.Errorf("expected every registered hook to be called, got %d starts", counter.starts)
+	}
+}
@@ -0,0 +1,239 @@
+package sidetree
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// The handler serves the REST API of a Sidetree node, as ION exposes it:
+//
+//	GET /identifiers/{did}
+//
+// returns the DID resolution result of a DID of the handler's method, 404 Not
+// Found for a DID that was never created and 410 Gone, with the deactivated
+// result, for a deactivated DID. A long-form DID (did:<method>:<suffix>:<long
+// form>) resolves like its short form once anchored; until then it resolves to
+// the unpublished DID its embedded create request creates.
+//
+//	POST /operations
+//
+// is served when the handler has an OperationQueue (WithOperationQueue). It
+// validates the operation request in the body (see ParseOperationRequest) and
+// queues it for anchoring; for a create it returns the resolution result of
+// the new, unpublished DID. An invalid request is 400 Bad Request.
+//
+// Errors are JSON objects with a code and a message.
+
+// HandlerOption configures the handler NewHandler returns.
+type HandlerOption func(h *handler)
+
+// WithOperationQueue serves POST /operations, queuing valid requests on queue.
+func WithOperationQueue(queue OperationQueue) HandlerOption {
+	return func(h *handler) {
+		h.queue = queue
+	}
+}
+
+// maxOperationRequestBytes bounds the body of an operation request. A valid
+// request is far smaller: its delta is at most MaxDeltaSizeInBytes.
+const maxOperationRequestBytes = 64 * 1024
+
+type handler struct {
+	prefix   string
+	resolver Resolver
+	queue    OperationQueue
+	mux      *http.ServeMux
+}
+
+// NewHandler returns an http.Handler serving the Sidetree REST API for DIDs
+// of the method prefix, resolved by resolver.
+func NewHandler(prefix string, resolver Resolver, opts ...HandlerOption) http.Handler {
+	h := &handler{prefix: prefix, resolver: resolver, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("GET /identifiers/{did}", h.resolve)
+	if h.queue != nil {
+		h.mux.HandleFunc("POST /operations", h.submit)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// resolutionResult is a DID resolution result as the handler writes it: the
+// resolver's, with the deactivated flag the DID resolution spec defines.
+type resolutionResult struct {
+	Context  string             `json:"@context"`
+	Document *did.DocumentData  `json:"didDocument"`
+	Metadata resolutionMetadata `json:"didDocumentMetadata"`
+}
+
+type resolutionMetadata struct {
+	Deactivated bool `json:"deactivated,omitempty"`
+	did.Metadata
+}
+
+func newResolutionResult(doc *did.Document, deactivated bool) resolutionResult {
+	return resolutionResult{
+		Context:  doc.Context,
+		Document: doc.Document,
+		Metadata: resolutionMetadata{Deactivated: deactivated, Metadata: doc.Metadata},
+	}
+}
+
+func (h *handler) resolve(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("did")
+	suffix, create, err := h.parseDID(id)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_did", err)
+		return
+	}
+
+	doc, err := h.resolver.Resolve(suffix)
+	if errors.Is(err, ErrDIDNotFound) && create != nil {
+		doc, err = h.unpublished(suffix, create)
+	}
+	switch {
+	case errors.Is(err, ErrDIDNotFound):
+		writeError(w, http.StatusNotFound, "did_not_found", err)
+		return
+	case errors.Is(err, ErrDIDDeactivated) && doc != nil:
+		writeJSON(w, http.StatusGone, newResolutionResult(doc, true))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal_error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newResolutionResult(doc, false))
+}
+
+func (h *handler) submit(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOperationRequestBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "request_too_large", err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_operation", err)
+		return
+	}
+	op, err := ParseOperationRequest(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_operation", err)
+		return
+	}
+	if _, err := h.queue.Enqueue(body); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err)
+		return
+	}
+
+	create, ok := op.(*operations.Create)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	suffix, err := create.SuffixData.URI()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err)
+		return
+	}
+	doc, err := h.unpublished(suffix, create)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newResolutionResult(doc, false))
+}
+
+// unpublished returns the document of the DID create creates, before it is
+// anchored.
+func (h *handler) unpublished(suffix string, create *operations.Create) (*did.Document, error) {
+	created := &replay{prefix: h.prefix, suffix: suffix}
+	if err := created.applyCreate(create); err != nil {
+		return nil, err
+	}
+	return created.doc, nil
+}
+
+// parseDID returns the suffix of id, a DID of the handler's method, and for a
+// long-form DID the create request it embeds.
+func (h *handler) parseDID(id string) (string, *operations.Create, error) {
+	method := "did:" + h.prefix + ":"
+	rest, ok := strings.CutPrefix(id, method)
+	if !ok {
+		return "", nil, fmt.Errorf("%q is not a %s DID", id, strings.TrimSuffix(method, ":"))
+	}
+	suffix, longForm, isLongForm := strings.Cut(rest, ":")
+	if err := checkEncodedMultihash(suffix); err != nil {
+		return "", nil, fmt.Errorf("%w: %q: %w", ErrInvalidDIDSuffix, suffix, err)
+	}
+	if !isLongForm {
+		return suffix, nil, nil
+	}
+	create, err := longFormCreate(suffix, longForm)
+	if err != nil {
+		return "", nil, err
+	}
+	return suffix, create, nil
+}
+
+// longFormCreate decodes the long-form part of a DID of suffix: the
+// base64url-encoded JSON suffix data and delta of its create request. The
+// request is checked like a create ParseOperationRequest accepts, and must
+// create suffix.
+func longFormCreate(suffix, longForm string) (*operations.Create, error) {
+	data, err := base64.RawURLEncoding.DecodeString(longForm)
+	if err != nil {
+		return nil, fmt.Errorf("%w: long-form DID: %w", ErrMalformed, err)
+	}
+	var fields struct {
+		SuffixData did.SuffixData `json:"suffixData"`
+		Delta      did.Delta      `json:"delta"`
+	}
+	if err := decodeJSON(DecodeStrict, data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: long-form DID: %w", ErrMalformed, err)
+	}
+	create := operations.CreateOperation(fields.SuffixData)
+	create.SetDelta(fields.Delta)
+	if err := ValidateOperation(create); err != nil {
+		return nil, err
+	}
+	created, err := create.SuffixData.URI()
+	if err != nil {
+		return nil, fmt.Errorf("%w: long-form DID: %w", ErrInvalidDIDSuffix, err)
+	}
+	if created != suffix {
+		return nil, fmt.Errorf("%w: long-form DID creates %q, not %q", ErrInvalidDIDSuffix, created, suffix)
+	}
+	return create, nil
+}
+
+// apiError is the body of an error response.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string, err error) {
+	writeJSON(w, status, apiError{Code: code, Message: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status is sent; a failed write can only be a closed connection.
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,294 @@
+package sidetree
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+)
+
+// failingResolver fails every resolution with err.
+type failingResolver struct{ err error }
+
+func (r failingResolver) Resolve(string) (*did.Document, error) { return nil, r.err }
+
+// longForm returns the long-form part of the DID d: its create request's
+// suffix data and delta, as a wallet encodes them.
+func longForm(t *testing.T, d testDID) string {
+	t.Helper()
+	data, err := json.Marshal(struct {
+		SuffixData did.SuffixData `json:"suffixData"`
+		Delta      did.Delta      `json:"delta"`
+	}{d.create.SuffixData, d.create.Delta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestHandlerResolve(t *testing.T) {
+	created := newTestDID(t, "created")
+	updated := newTestDID(t, "updated")
+	deactivated := newTestDID(t, "deactivated")
+	unanchored := newTestDID(t, "unanchored")
+	state := NewState("test")
+	applyAnchors(t, state,
+		testAnchor(1, created.create, updated.create, deactivated.create),
+		testAnchor(2, deactivated.deactivate(t, deactivated.recoveryKey), updated.update(t, updated.updateKey, "anchored", updated.nextUpdateKey)))
+
+	type response struct {
+		DIDDocument struct {
+			ID string `json:"id"`
+		} `json:"didDocument"`
+		DIDDocumentMetadata struct {
+			Deactivated bool   `json:"deactivated"`
+			CanonicalID string `json:"canonicalId"`
+			Method      struct {
+				Published          bool   `json:"published"`
+				UpdateCommitment   string `json:"updateCommitment"`
+				RecoveryCommitment string `json:"recoveryCommitment"`
+			} `json:"method"`
+		} `json:"didDocumentMetadata"`
+		Code string `json:"code"`
+	}
+
+	tests := map[string]struct {
+		method     string
+		path       string
+		resolver   Resolver
+		wantStatus int
+		wantCode   string
+		check      func(t *testing.T, got response)
+	}{
+		"published": {
+			path:       "/identifiers/did:test:" + created.suffix,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, got response) {
+				meta := got.DIDDocumentMetadata
+				if got.DIDDocument.ID != "did:test:"+created.suffix || meta.CanonicalID != got.DIDDocument.ID {
+					t.Errorf("expected did:test:%s, got %+v", created.suffix, got)
+				}
+				if !meta.Method.Published || meta.Deactivated {
+					t.Errorf("expected a published, active DID, got %+v", meta)
+				}
+				if meta.Method.UpdateCommitment != testCommitment(t, created.updateKey.reveal) ||
+					meta.Method.RecoveryCommitment != testCommitment(t, created.recoveryKey.reveal) {
+					t.Errorf("expected the create's commitments, got %+v", meta.Method)
+				}
+			},
+		},
+		"deactivated": {
+			path:       "/identifiers/did:test:" + deactivated.suffix,
+			wantStatus: http.StatusGone,
+			check: func(t *testing.T, got response) {
+				if !got.DIDDocumentMetadata.Deactivated || got.DIDDocument.ID != "did:test:"+deactivated.suffix {
+					t.Errorf("expected a deactivated did:test:%s, got %+v", deactivated.suffix, got)
+				}
+			},
+		},
+		"long-form, anchored": {
+			path:       "/identifiers/did:test:" + updated.suffix + ":" + longForm(t, updated),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, got response) {
+				meta := got.DIDDocumentMetadata
+				if got.DIDDocument.ID != "did:test:"+updated.suffix || !meta.Method.Published {
+					t.Errorf("expected the published did:test:%s, got %+v", updated.suffix, got)
+				}
+				if meta.Method.UpdateCommitment != testCommitment(t, updated.nextUpdateKey.reveal) {
+					t.Errorf("expected the anchored update's commitment, got %+v", meta.Method)
+				}
+			},
+		},
+		"long-form, deactivated": {
+			path:       "/identifiers/did:test:" + deactivated.suffix + ":" + longForm(t, deactivated),
+			wantStatus: http.StatusGone,
+		},
+		"long-form, not anchored": {
+			path:       "/identifiers/did:test:" + unanchored.suffix + ":" + longForm(t, unanchored),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, got response) {
+				meta := got.DIDDocumentMetadata
+				if got.DIDDocument.ID != "did:test:"+unanchored.suffix || meta.Method.Published {
+					t.Errorf("expected the unpublished did:test:%s, got %+v", unanchored.suffix, got)
+				}
+				if meta.Method.UpdateCommitment != testCommitment(t, unanchored.updateKey.reveal) ||
+					meta.Method.RecoveryCommitment != testCommitment(t, unanchored.recoveryKey.reveal) {
+					t.Errorf("expected the embedded create's commitments, got %+v", meta.Method)
+				}
+			},
+		},
+		"long-form of another DID": {
+			path:       "/identifiers/did:test:" + unanchored.suffix + ":" + longForm(t, created),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_did",
+		},
+		"long-form not base64url": {
+			path:       "/identifiers/did:test:" + unanchored.suffix + ":not+base64",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_did",
+		},
+		"not found": {
+			path:       "/identifiers/did:test:" + encodedSHA256Multihash([]byte("unknown")),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "did_not_found",
+		},
+		"another method": {
+			path:       "/identifiers/did:other:" + created.suffix,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_did",
+		},
+		"invalid suffix": {
+			path:       "/identifiers/did:test:not-a-suffix",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_did",
+		},
+		"resolver failure": {
+			path:       "/identifiers/did:test:" + created.suffix,
+			resolver:   failingResolver{errors.New("storage offline")},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "internal_error",
+		},
+		"wrong method": {
+			method:     http.MethodDelete,
+			path:       "/identifiers/did:test:" + created.suffix,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resolver := test.resolver
+			if resolver == nil {
+				resolver = state
+			}
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler("test", resolver).ServeHTTP(rec, httptest.NewRequest(method, test.path, nil))
+			if rec.Code != test.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.wantStatus, rec.Code, rec.Body)
+			}
+			if test.wantStatus == http.StatusMethodNotAllowed {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected a JSON response, got %q", ct)
+			}
+
+			var got response
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("expected a JSON body, got %v: %s", err, rec.Body)
+			}
+			if got.Code != test.wantCode {
+				t.Errorf("expected error code %q, got %q", test.wantCode, got.Code)
+			}
+			if test.check != nil {
+				test.check(t, got)
+			}
+		})
+	}
+}
+
+func TestHandlerSubmit(t *testing.T) {
+	d := newTestDID(t, "submitted")
+	marshal := func(op interface{}) string {
+		data, err := json.Marshal(op)
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		return string(data)
+	}
+
+	tests := map[string]struct {
+		body       string
+		wantStatus int
+		wantCode   string
+		wantID     string
+		wantQueued bool
+	}{
+		"create": {
+			body:       marshal(d.create),
+			wantStatus: http.StatusOK,
+			wantID:     "did:test:" + d.suffix,
+			wantQueued: true,
+		},
+		"update": {
+			body:       marshal(d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+			wantStatus: http.StatusOK,
+			wantQueued: true,
+		},
+		"invalid": {
+			body:       `{"type":"deactivate","didSuffix":"not-a-suffix"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_operation",
+		},
+		"too large": {
+			body:       `{"type":"create","pad":"` + strings.Repeat("x", maxOperationRequestBytes) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "request_too_large",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			queue := newTestQueue(t)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/operations", strings.NewReader(test.body))
+			NewHandler("test", NewState("test"), WithOperationQueue(queue)).ServeHTTP(rec, req)
+			if rec.Code != test.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.wantStatus, rec.Code, rec.Body)
+			}
+
+			var got struct {
+				DIDDocument struct {
+					ID string `json:"id"`
+				} `json:"didDocument"`
+				DIDDocumentMetadata struct {
+					Method struct {
+						Published bool `json:"published"`
+					} `json:"method"`
+				} `json:"didDocumentMetadata"`
+				Code string `json:"code"`
+			}
+			if rec.Body.Len() > 0 {
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatalf("expected a JSON body, got %v: %s", err, rec.Body)
+				}
+			}
+			if got.Code != test.wantCode {
+				t.Errorf("expected error code %q, got %q", test.wantCode, got.Code)
+			}
+			if got.DIDDocument.ID != test.wantID || got.DIDDocumentMetadata.Method.Published {
+				t.Errorf("expected an unpublished %q, got %+v", test.wantID, got)
+			}
+
+			pending, _ := queue.Pending()
+			if queued := len(pending) == 1; queued != test.wantQueued {
+				t.Errorf("expected queued %t, got %v", test.wantQueued, pending)
+			}
+		})
+	}
+}
+
+func TestHandlerWithoutQueue(t *testing.T) {
+	d := newTestDID(t, "unqueued")
+	body, err := json.Marshal(d.create)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/operations", strings.NewReader(string(body)))
+	NewHandler("test", NewState("test")).ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d without a queue, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,477 @@
+package localcas
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	sidetree "github.com/13x-tech/sidetree-go"
+)
+
+// CARType is the CASType of a CAR.
+const CARType sidetree.CASType = "car"
+
+// ErrReadOnly is returned by Put on a CAS that cannot store objects.
+var ErrReadOnly = errors.New("CAS is read-only")
+
+// maxCARHeaderSize bounds the header of an archive; a Sidetree export has a
+// single root.
+const maxCARHeaderSize = 1 << 16
+
+// maxCARSectionSize bounds one block of an archive: a CID and the largest
+// Sidetree file the protocol allows.
+const maxCARSectionSize = sidetree.MaxChunkFileSizeInBytes + 1<<10
+
+// maxCARBlocks bounds the blocks of an archive: an export holds the five files
+// of one anchor and its URI map.
+const maxCARBlocks = 16
+
+// maxCARSize bounds the total size of the blocks of an archive: every file of
+// one anchor at its size cap, and room for the CIDs and the URI map.
+const maxCARSize = sidetree.MaxCoreIndexFileSizeInBytes + sidetree.MaxProvisionalIndexFileSizeInBytes +
+	2*sidetree.MaxProofFileSizeInBytes + sidetree.MaxChunkFileSizeInBytes + 1<<20
+
+// CAR is a read-only CAS serving the blocks of a CARv1 archive, so that an
+// anchor exported with ExportCAR can be replayed offline. Blocks are looked up
+// by the anchor's file URIs, through the archive's URI map for a file whose
+// URI is not the CID of its bytes, and decompressed like Dir objects.
+type CAR struct {
+	roots  []string
+	blocks map[string][]byte
+	uris   map[string][]byte
+}
+
+var _ sidetree.CAS = (*CAR)(nil)
+
+// ReadCAR loads a CARv1 archive, checking that every block hashes to its CID.
+// An archive of more than maxCARBlocks blocks or maxCARSize bytes of blocks is
+// rejected.
+func ReadCAR(r io.Reader) (*CAR, error) {
+	br := bufio.NewReader(r)
+
+	header, err := readSection(br, maxCARHeaderSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CAR header: %w", err)
+	}
+	roots, err := decodeCARHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CAR{blocks: map[string][]byte{}, uris: map[string][]byte{}}
+	size := 0
+	for {
+		section, err := readSection(br, maxCARSectionSize)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CAR block: %w", err)
+		}
+		if len(c.blocks) == maxCARBlocks {
+			return nil, fmt.Errorf("CAR holds more than %d blocks", maxCARBlocks)
+		}
+		if size += len(section); size > maxCARSize {
+			return nil, fmt.Errorf("CAR blocks exceed %d bytes", maxCARSize)
+		}
+		cid, _, err := splitCID(section)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CAR block: %w", err)
+		}
+		data := section[len(cid):]
+		if err := checkBlock(cid, data); err != nil {
+			return nil, fmt.Errorf("invalid CAR block: %w", err)
+		}
+		c.blocks[string(cid)] = data
+	}
+
+	if len(roots) > 0 && isURIMap(roots[len(roots)-1]) {
+		if err := c.readURIMap(roots[len(roots)-1]); err != nil {
+			return nil, err
+		}
+		roots = roots[:len(roots)-1]
+	}
+	for _, root := range roots {
+		c.roots = append(c.roots, c.uri(root))
+	}
+	return c, nil
+}
+
+// readURIMap loads the URI map block root: a DAG-JSON object from each file
+// URI that is not the CID of the file's bytes to the CID of its block.
+func (c *CAR) readURIMap(root []byte) error {
+	data, ok := c.blocks[string(root)]
+	if !ok {
+		return fmt.Errorf("invalid CAR: missing URI map %s", formatCID(root))
+	}
+	var uris map[string]string
+	if err := json.Unmarshal(data, &uris); err != nil {
+		return fmt.Errorf("invalid CAR URI map: %w", err)
+	}
+	for uri, id := range uris {
+		cid, err := parseCID(id)
+		if err != nil {
+			return fmt.Errorf("invalid CAR URI map: %w", err)
+		}
+		if _, ok := c.blocks[string(cid)]; !ok {
+			return fmt.Errorf("invalid CAR URI map: %s maps to missing block %s", uri, id)
+		}
+		c.uris[uri] = cid
+	}
+	return nil
+}
+
+// uri returns the URI the block cid is fetched by.
+func (c *CAR) uri(cid []byte) string {
+	for uri, mapped := range c.uris {
+		if bytes.Equal(mapped, cid) {
+			return uri
+		}
+	}
+	return formatCID(cid)
+}
+
+// OpenCAR loads the CARv1 archive at path.
+func OpenCAR(path string) (*CAR, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCAR(f)
+}
+
+// Roots returns the URIs of the root blocks of the archive; for an export,
+// the core index file of the anchor.
+func (c *CAR) Roots() []string {
+	return slices.Clone(c.roots)
+}
+
+func (c *CAR) Start() error {
+	return nil
+}
+
+func (c *CAR) Close() error {
+	return nil
+}
+
+func (c *CAR) Type() sidetree.CASType {
+	return CARType
+}
+
+// Get returns the block for the CID string id, decompressed. An id that is
+// not a CID or not in the archive wraps sidetree.ErrURINotFound.
+func (c *CAR) Get(id string, maxSizeInBytes int) ([]byte, error) {
+	stored, err := c.getStored(id, maxSizeInBytes)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(id, stored, maxSizeInBytes)
+}
+
+func (c *CAR) getStored(id string, maxSizeInBytes int) ([]byte, error) {
+	cid, ok := c.uris[id]
+	if !ok {
+		var err error
+		if cid, err = parseCID(id); err != nil {
+			return nil, fmt.Errorf("%w: %w", sidetree.ErrURINotFound, err)
+		}
+	}
+	data, ok := c.blocks[string(cid)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", sidetree.ErrURINotFound, id)
+	}
+	if len(data) > maxSizeInBytes {
+		return data[:maxSizeInBytes+1], nil
+	}
+	return data, nil
+}
+
+// Put always fails: an archive is written whole by WriteCAR.
+func (c *CAR) Put(data []byte) (string, error) {
+	return "", ErrReadOnly
+}
+
+// Block is one file of a CAR archive.
+type Block struct {
+	// URI is the id the file is fetched by.
+	URI  string
+	Data []byte
+}
+
+// WriteCAR writes a CARv1 archive of blocks with the given roots, the URIs of
+// some of the blocks. A block whose URI is a CIDv0 or base32 CIDv1 that Data
+// hashes to is stored under it. Any other block, such as an ION file named by
+// the CIDv0 of the dag-pb UnixFS node that wraps it, is stored as a raw block
+// under the CIDv1 of Data, and a DAG-JSON URI map from its URI to that CID is
+// added to the archive as the last root.
+func WriteCAR(w io.Writer, roots []string, blocks []Block) error {
+	cids := make(map[string][]byte, len(blocks))
+	uris := map[string]string{}
+	for _, block := range blocks {
+		cid, err := parseCID(block.URI)
+		if err != nil || checkBlock(cid, block.Data) != nil {
+			cid = cidBytes(block.Data)
+			uris[block.URI] = cidString(cid)
+		}
+		cids[block.URI] = cid
+	}
+
+	rootCIDs := make([][]byte, len(roots))
+	for i, root := range roots {
+		cid, ok := cids[root]
+		if !ok {
+			return fmt.Errorf("root %s is not a block of the archive", root)
+		}
+		rootCIDs[i] = cid
+	}
+	var uriMap []byte
+	if len(uris) > 0 {
+		var err error
+		if uriMap, err = json.Marshal(uris); err != nil {
+			return err
+		}
+		rootCIDs = append(rootCIDs, codecCIDBytes(dagJSONCodec, uriMap))
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeSection(bw, encodeCARHeader(rootCIDs)); err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if err := writeSection(bw, cids[block.URI], block.Data); err != nil {
+			return err
+		}
+	}
+	if uriMap != nil {
+		if err := writeSection(bw, rootCIDs[len(rootCIDs)-1], uriMap); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// readSection reads one varint length-prefixed section. It returns io.EOF
+// only at a clean end of input.
+func readSection(r *bufio.Reader, maxSize int) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if size > uint64(maxSize) {
+		return nil, fmt.Errorf("section of %d bytes exceeds %d", size, maxSize)
+	}
+	section := make([]byte, size)
+	if _, err := io.ReadFull(r, section); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return section, nil
+}
+
+func writeSection(w io.Writer, parts ...[]byte) error {
+	size := 0
+	for _, part := range parts {
+		size += len(part)
+	}
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(size))); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The CARv1 header is the DAG-CBOR map {"roots": [CID...], "version": 1}.
+// Only the subset of CBOR the header uses is supported.
+
+const (
+	cborUint  = 0
+	cborBytes = 2
+	cborText  = 3
+	cborArray = 4
+	cborMap   = 5
+	cborTag   = 6
+
+	// cborTagCID is the DAG-CBOR tag of a CID link.
+	cborTagCID = 42
+)
+
+func encodeCARHeader(roots [][]byte) []byte {
+	var b []byte
+	b = appendCBORHead(b, cborMap, 2)
+	b = appendCBORHead(b, cborText, 5)
+	b = append(b, "roots"...)
+	b = appendCBORHead(b, cborArray, uint64(len(roots)))
+	for _, root := range roots {
+		b = appendCBORHead(b, cborTag, cborTagCID)
+		// A DAG-CBOR link is the binary CID behind a 0x00 multibase prefix.
+		b = appendCBORHead(b, cborBytes, uint64(len(root))+1)
+		b = append(b, 0)
+		b = append(b, root...)
+	}
+	b = appendCBORHead(b, cborText, 7)
+	b = append(b, "version"...)
+	return appendCBORHead(b, cborUint, 1)
+}
+
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	m := major << 5
+	switch {
+	case n < 24:
+		return append(b, m|byte(n))
+	case n <= 0xff:
+		return append(b, m|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, m|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, m|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, m|27), n)
+	}
+}
+
+func decodeCARHeader(header []byte) ([][]byte, error) {
+	d := cborDecoder{b: header}
+	invalid := func(err error) ([][]byte, error) {
+		return nil, fmt.Errorf("invalid CAR header: %w", err)
+	}
+
+	entries, err := d.head(cborMap)
+	if err != nil {
+		return invalid(err)
+	}
+	var roots [][]byte
+	var version uint64
+	for i := uint64(0); i < entries; i++ {
+		key, err := d.text()
+		if err != nil {
+			return invalid(err)
+		}
+		switch key {
+		case "version":
+			if version, err = d.head(cborUint); err != nil {
+				return invalid(err)
+			}
+		case "roots":
+			n, err := d.head(cborArray)
+			if err != nil {
+				return invalid(err)
+			}
+			for j := uint64(0); j < n; j++ {
+				root, err := d.link()
+				if err != nil {
+					return invalid(err)
+				}
+				roots = append(roots, root)
+			}
+		default:
+			return invalid(fmt.Errorf("unexpected key %q", key))
+		}
+	}
+	if len(d.b) != 0 {
+		return invalid(fmt.Errorf("trailing bytes"))
+	}
+	if version != 1 {
+		return nil, fmt.Errorf("unsupported CAR version %d", version)
+	}
+	return roots, nil
+}
+
+type cborDecoder struct {
+	b []byte
+}
+
+var errCBORTruncated = errors.New("truncated CBOR")
+
+// head decodes an item head of the given major type and returns its argument.
+func (d *cborDecoder) head(major byte) (uint64, error) {
+	if len(d.b) == 0 {
+		return 0, errCBORTruncated
+	}
+	if d.b[0]>>5 != major {
+		return 0, fmt.Errorf("expected CBOR major type %d, got %d", major, d.b[0]>>5)
+	}
+	info := d.b[0] & 0x1f
+	d.b = d.b[1:]
+
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, fmt.Errorf("unsupported CBOR additional information %d", info)
+	}
+	if len(d.b) < size {
+		return 0, errCBORTruncated
+	}
+	var n uint64
+	for _, c := range d.b[:size] {
+		n = n<<8 | uint64(c)
+	}
+	d.b = d.b[size:]
+	return n, nil
+}
+
+func (d *cborDecoder) bytes(major byte) ([]byte, error) {
+	n, err := d.head(major)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.b)) {
+		return nil, errCBORTruncated
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	b, err := d.bytes(cborText)
+	return string(b), err
+}
+
+// link decodes a DAG-CBOR CID link.
+func (d *cborDecoder) link() ([]byte, error) {
+	tag, err := d.head(cborTag)
+	if err != nil {
+		return nil, err
+	}
+	if tag != cborTagCID {
+		return nil, fmt.Errorf("unexpected CBOR tag %d", tag)
+	}
+	b, err := d.bytes(cborBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || b[0] != 0 {
+		return nil, fmt.Errorf("invalid CID link")
+	}
+	cid, _, err := splitCID(b[1:])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(cid, b[1:]) {
+		return nil, fmt.Errorf("invalid CID link: trailing bytes")
+	}
+	return cid, nil
+}
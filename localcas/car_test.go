@@ -0,0 +1,280 @@
+package localcas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	sidetree "github.com/13x-tech/sidetree-go"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestParseCID(t *testing.T) {
+	v0, err := mh.Sum([]byte("block"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		cid     string
+		wantErr bool
+	}{
+		"CIDv1":             {cid: CID([]byte("block"))},
+		"CIDv0":             {cid: v0.B58String()},
+		"not a CID":         {cid: "core-index", wantErr: true},
+		"truncated CIDv1":   {cid: CID([]byte("block"))[:20], wantErr: true},
+		"uppercase base32":  {cid: "B" + CID([]byte("block"))[1:], wantErr: true},
+		"bad CIDv0 payload": {cid: "Qm" + string(bytes.Repeat([]byte{'0'}, 44)), wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cid, err := parseCID(test.cid)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %x", cid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := formatCID(cid); got != test.cid {
+				t.Errorf("expected %s to round-trip, got %s", test.cid, got)
+			}
+			if err := checkBlock(cid, []byte("block")); err != nil {
+				t.Errorf("expected the block to match its CID, got %v", err)
+			}
+			if err := checkBlock(cid, []byte("other")); err == nil {
+				t.Errorf("expected other data not to match the CID")
+			}
+		})
+	}
+}
+
+func TestCARHeader(t *testing.T) {
+	root, err := parseCID(CID(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a2" + "65" + hex.EncodeToString([]byte("roots")) + "81" + "d82a" + "5825" + "00" + hex.EncodeToString(root) +
+		"67" + hex.EncodeToString([]byte("version")) + "01"
+	if got := hex.EncodeToString(encodeCARHeader([][]byte{root})); got != want {
+		t.Errorf("expected header %s, got %s", want, got)
+	}
+
+	roots, err := decodeCARHeader(encodeCARHeader([][]byte{root}))
+	if err != nil {
+		t.Fatalf("expected no error decoding header, got %v", err)
+	}
+	if len(roots) != 1 || !bytes.Equal(roots[0], root) {
+		t.Errorf("expected roots [%x], got %x", root, roots)
+	}
+
+	for name, header := range map[string][]byte{
+		"version 2":   {0xa1, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x02},
+		"not a map":   {0x80},
+		"truncated":   {0xa2, 0x65, 'r', 'o'},
+		"unknown key": {0xa1, 0x61, 'x', 0x01},
+	} {
+		if _, err := decodeCARHeader(header); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestExportCAR(t *testing.T) {
+	dir := NewDir(t.TempDir())
+	if err := dir.Start(); err != nil {
+		t.Fatal(err)
+	}
+	update := operations.UpdateOperation("update-did", "reveal", "signed-data")
+	deactivate := operations.DeactivateOperation("deactivate-did", "reveal", "signed-data")
+	batch, err := sidetree.NewBatch([]interface{}{update, deactivate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchor, err := batch.Write(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := ExportCAR(&archive, operations.Anchor{Anchor: anchor}, dir, sidetree.WithPrefix("ion")); err != nil {
+		t.Fatalf("expected no error exporting, got %v", err)
+	}
+
+	car, err := ReadCAR(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error reading the archive, got %v", err)
+	}
+	if _, uri, _ := strings.Cut(string(anchor), "."); len(car.Roots()) != 1 || car.Roots()[0] != uri {
+		t.Errorf("expected the core index %s as root, got %v", uri, car.Roots())
+	}
+	if len(car.blocks) != 5 {
+		t.Errorf("expected 5 blocks, got %d", len(car.blocks))
+	}
+	if _, err := car.Put([]byte("x")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected %v, got %v", ErrReadOnly, err)
+	}
+
+	p, err := sidetree.Processor(operations.Anchor{Anchor: anchor}, sidetree.WithCAS(car), sidetree.WithPrefix("ion"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := p.Process()
+	if got.Error != nil {
+		t.Fatalf("expected the archive to replay, got %v", got.Error)
+	}
+	if len(got.UpdateOps) != 1 || len(got.DeactivateOps) != 1 {
+		t.Errorf("expected one update and one deactivate, got %+v", got)
+	}
+
+	t.Run("tampered block", func(t *testing.T) {
+		tampered := bytes.Clone(archive.Bytes())
+		tampered[len(tampered)-1] ^= 0xff
+		if _, err := ReadCAR(bytes.NewReader(tampered)); err == nil {
+			t.Errorf("expected an error reading a tampered archive")
+		}
+	})
+
+	t.Run("truncated archive", func(t *testing.T) {
+		if _, err := ReadCAR(bytes.NewReader(archive.Bytes()[:archive.Len()-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := ExportCAR(&bytes.Buffer{}, operations.Anchor{Anchor: operations.AnchorString("1." + CID([]byte("missing")))}, dir, sidetree.WithPrefix("ion"))
+		if !errors.Is(err, sidetree.ErrContentUnavailable) {
+			t.Errorf("expected %v, got %v", sidetree.ErrContentUnavailable, err)
+		}
+	})
+}
+
+// unixFSCID returns the CIDv0 `ipfs add` reports for data: the SHA-256 of the
+// dag-pb node wrapping data in a single-block UnixFS file.
+func unixFSCID(data []byte) string {
+	file := []byte{0x08, 0x02} // Type: File
+	file = append(file, 0x12)
+	file = binary.AppendUvarint(file, uint64(len(data)))
+	file = append(file, data...)
+	file = append(file, 0x18)
+	file = binary.AppendUvarint(file, uint64(len(data)))
+	node := append([]byte{0x0a}, binary.AppendUvarint(nil, uint64(len(file)))...)
+	// SHA-256 with its default length cannot fail.
+	hash, _ := mh.Sum(append(node, file...), mh.SHA2_256, -1)
+	return hash.B58String()
+}
+
+// unixFSDir is a Dir whose objects are named like those of an IPFS node
+// publishing ION files: by the CIDv0 of their UnixFS node.
+type unixFSDir struct {
+	*Dir
+}
+
+func (d unixFSDir) Put(data []byte) (string, error) {
+	compressed, err := sidetree.CompressFile(data)
+	if err != nil {
+		return "", err
+	}
+	id := unixFSCID(compressed)
+	return id, os.WriteFile(filepath.Join(d.path, id), compressed, 0o644)
+}
+
+func TestExportCARCIDv0(t *testing.T) {
+	// `echo "hello world" | ipfs add`
+	if got, want := unixFSCID([]byte("hello world\n")), "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o"; got != want {
+		t.Fatalf("expected CIDv0 %s, got %s", want, got)
+	}
+
+	dir := unixFSDir{NewDir(t.TempDir())}
+	deactivate := operations.DeactivateOperation("deactivate-did", "reveal", "signed-data")
+	batch, err := sidetree.NewBatch([]interface{}{deactivate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchor, err := batch.Write(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, uri, _ := strings.Cut(string(anchor), ".")
+	if !strings.HasPrefix(uri, "Qm") {
+		t.Fatalf("expected a CIDv0 core index URI, got %s", uri)
+	}
+
+	var archive bytes.Buffer
+	if err := ExportCAR(&archive, operations.Anchor{Anchor: anchor}, dir, sidetree.WithPrefix("ion")); err != nil {
+		t.Fatalf("expected no error exporting, got %v", err)
+	}
+	car, err := ReadCAR(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error reading the archive, got %v", err)
+	}
+	if roots := car.Roots(); len(roots) != 1 || roots[0] != uri {
+		t.Errorf("expected the core index %s as root, got %v", uri, roots)
+	}
+
+	p, err := sidetree.Processor(operations.Anchor{Anchor: anchor}, sidetree.WithCAS(car), sidetree.WithPrefix("ion"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Process(); got.Error != nil || len(got.DeactivateOps) != 1 {
+		t.Errorf("expected the archive to replay one deactivate, got %+v", got)
+	}
+}
+
+func TestWriteCARUnknownRoot(t *testing.T) {
+	err := WriteCAR(&bytes.Buffer{}, []string{"core-index"}, []Block{{URI: "other", Data: []byte("x")}})
+	if err == nil {
+		t.Errorf("expected an error for a root that is not a block")
+	}
+}
+
+func TestReadCARLimits(t *testing.T) {
+	tests := map[string]func(w io.Writer) error{
+		"too many blocks": func(w io.Writer) error {
+			if err := writeSection(w, encodeCARHeader(nil)); err != nil {
+				return err
+			}
+			for i := range maxCARBlocks + 1 {
+				data := []byte{byte(i)}
+				if err := writeSection(w, cidBytes(data), data); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		"too many bytes": func(w io.Writer) error {
+			if err := writeSection(w, encodeCARHeader(nil)); err != nil {
+				return err
+			}
+			for i := 0; i <= maxCARSize/sidetree.MaxChunkFileSizeInBytes; i++ {
+				data := bytes.Repeat([]byte{byte(i)}, sidetree.MaxChunkFileSizeInBytes)
+				if err := writeSection(w, cidBytes(data), data); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	for name, write := range tests {
+		t.Run(name, func(t *testing.T) {
+			var archive bytes.Buffer
+			if err := write(&archive); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadCAR(&archive); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
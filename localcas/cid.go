@@ -0,0 +1,168 @@
+package localcas
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Multicodec codes of the IPFS blocks an archive writes: a raw binary file and
+// the DAG-JSON URI map.
+const (
+	rawCodec     = 0x55
+	dagJSONCodec = 0x0129
+)
+
+// cidEncoding is the multibase base32 alphabet ("b" prefix) IPFS uses for
+// CIDv1 strings.
+var cidEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// CID returns the CIDv1 string of data stored as a raw block with a SHA-256
+// multihash, as `ipfs add --cid-version 1 --raw-leaves` reports for a file
+// that fits one block.
+func CID(data []byte) string {
+	return cidString(cidBytes(data))
+}
+
+// cidBytes returns the binary CIDv1 of data as a raw block.
+func cidBytes(data []byte) []byte {
+	return codecCIDBytes(rawCodec, data)
+}
+
+// codecCIDBytes returns the binary CIDv1 of data as a block of codec.
+func codecCIDBytes(codec uint64, data []byte) []byte {
+	hash, err := mh.Sum(data, mh.SHA2_256, -1)
+	if err != nil {
+		// SHA-256 with its default length cannot fail.
+		panic(err)
+	}
+	b := binary.AppendUvarint(nil, 1)
+	b = binary.AppendUvarint(b, codec)
+	return append(b, hash...)
+}
+
+// cidString renders a binary CID in its base32 multibase form.
+func cidString(cid []byte) string {
+	return "b" + strings.ToLower(cidEncoding.EncodeToString(cid))
+}
+
+// parseCID decodes a CID string into its binary form: the bare multihash for
+// a CIDv0 ("Qm..." base58btc) and version, codec and multihash for a base32
+// CIDv1.
+func parseCID(s string) ([]byte, error) {
+	switch {
+	case len(s) == 46 && strings.HasPrefix(s, "Qm"):
+		hash, err := mh.FromB58String(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDv0 %q: %w", s, err)
+		}
+		return hash, nil
+	case strings.HasPrefix(s, "b"):
+		cid, err := cidEncoding.DecodeString(strings.ToUpper(s[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDv1 %q: %w", s, err)
+		}
+		prefix, _, err := splitCID(cid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDv1 %q: %w", s, err)
+		}
+		if len(prefix) != len(cid) {
+			return nil, fmt.Errorf("invalid CIDv1 %q: trailing bytes", s)
+		}
+		return cid, nil
+	default:
+		return nil, fmt.Errorf("%q is not a CIDv0 or base32 CIDv1", s)
+	}
+}
+
+// formatCID is the inverse of parseCID.
+func formatCID(cid []byte) string {
+	if isCIDv0(cid) {
+		return mh.Multihash(cid).B58String()
+	}
+	return cidString(cid)
+}
+
+// isCIDv0 reports whether cid starts with a SHA-256 multihash header, the
+// only form a binary CIDv0 takes.
+func isCIDv0(cid []byte) bool {
+	return len(cid) >= 2 && cid[0] == mh.SHA2_256 && cid[1] == 32
+}
+
+// splitCID returns the binary CID at the start of b and its multihash, and
+// checks that it is well formed; the CID ends where the multihash does.
+func splitCID(b []byte) (cid []byte, hash mh.Multihash, err error) {
+	if isCIDv0(b) {
+		if len(b) < 34 {
+			return nil, nil, fmt.Errorf("truncated CIDv0")
+		}
+		return b[:34], mh.Multihash(b[:34]), nil
+	}
+
+	_, n, m, err := cidPrefix(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	rest := b[n+m:]
+	code, k := binary.Uvarint(rest)
+	if k <= 0 {
+		return nil, nil, fmt.Errorf("invalid CID multihash")
+	}
+	length, l := binary.Uvarint(rest[k:])
+	if l <= 0 || length > uint64(len(rest)-k-l) {
+		return nil, nil, fmt.Errorf("invalid CID multihash")
+	}
+	end := n + m + k + l + int(length)
+	if _, err := mh.Cast(b[n+m : end]); err != nil {
+		return nil, nil, fmt.Errorf("invalid CID multihash (code %#x): %w", code, err)
+	}
+	return b[:end], mh.Multihash(b[n+m : end]), nil
+}
+
+// cidPrefix decodes the version and codec varints of a binary CIDv1 and
+// returns the codec and the length of each varint.
+func cidPrefix(b []byte) (codec uint64, versionLen, codecLen int, err error) {
+	version, n := binary.Uvarint(b)
+	if n <= 0 || version != 1 {
+		return 0, 0, 0, fmt.Errorf("unsupported CID version")
+	}
+	codec, m := binary.Uvarint(b[n:])
+	if m <= 0 {
+		return 0, 0, 0, fmt.Errorf("invalid CID codec")
+	}
+	return codec, n, m, nil
+}
+
+// isURIMap reports whether cid is the CIDv1 of a DAG-JSON block, the form of
+// the URI map WriteCAR adds to an archive.
+func isURIMap(cid []byte) bool {
+	if isCIDv0(cid) {
+		return false
+	}
+	codec, _, _, err := cidPrefix(cid)
+	return err == nil && codec == dagJSONCodec
+}
+
+// checkBlock reports whether data hashes to the multihash of cid.
+func checkBlock(cid []byte, data []byte) error {
+	_, hash, err := splitCID(cid)
+	if err != nil {
+		return err
+	}
+	decoded, err := mh.Decode(hash)
+	if err != nil {
+		return err
+	}
+	sum, err := mh.Sum(data, decoded.Code, decoded.Length)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, hash) {
+		return fmt.Errorf("block does not hash to %s", formatCID(cid))
+	}
+	return nil
+}
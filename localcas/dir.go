@@ -0,0 +1,137 @@
+// Package localcas provides sidetree.CAS implementations backed by local
+// files, for tooling, test fixtures and offline replay of an anchor: Dir, a
+// directory of objects, and CAR, a CARv1 archive of the files of one anchor as
+// written by ExportCAR. A node following a live network should use a CAS
+// backed by IPFS.
+package localcas
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sidetree "github.com/13x-tech/sidetree-go"
+)
+
+// DirType is the CASType of a Dir.
+const DirType sidetree.CASType = "dir"
+
+// Dir is a CAS that stores each object as a file named by its id in a single
+// directory. Put writes gzip-compressed objects named by their CID; Get also
+// serves uncompressed files, so hand-written JSON fixtures can be dropped
+// into the directory under any name.
+type Dir struct {
+	path string
+}
+
+var _ sidetree.CAS = (*Dir)(nil)
+
+func NewDir(path string) *Dir {
+	return &Dir{path: path}
+}
+
+// Start creates the directory if it does not exist.
+func (d *Dir) Start() error {
+	return os.MkdirAll(d.path, 0o755)
+}
+
+func (d *Dir) Close() error {
+	return nil
+}
+
+func (d *Dir) Type() sidetree.CASType {
+	return DirType
+}
+
+// Get returns the object stored under id, decompressed. A missing object
+// wraps sidetree.ErrURINotFound; an object over the size caps or with a
+// corrupt gzip stream wraps sidetree.ErrMalformed.
+func (d *Dir) Get(id string, maxSizeInBytes int) ([]byte, error) {
+	stored, err := d.getStored(id, maxSizeInBytes)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(id, stored, maxSizeInBytes)
+}
+
+// getStored returns at most maxSizeInBytes+1 bytes of the object stored under
+// id, as stored.
+func (d *Dir) getStored(id string, maxSizeInBytes int) ([]byte, error) {
+	if !validID(id) {
+		return nil, fmt.Errorf("%w: invalid id %q", sidetree.ErrURINotFound, id)
+	}
+
+	f, err := os.Open(filepath.Join(d.path, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", sidetree.ErrURINotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(io.LimitReader(f, int64(maxSizeInBytes)+1))
+}
+
+// Put stores data gzip-compressed and returns its CID.
+func (d *Dir) Put(data []byte) (string, error) {
+	compressed, err := sidetree.CompressFile(data)
+	if err != nil {
+		return "", err
+	}
+	id := CID(compressed)
+
+	tmp, err := os.CreateTemp(d.path, ".put-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(compressed); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(d.path, id)); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// validID reports whether id names a file directly inside the directory.
+func validID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
+
+// decompress enforces the CAS.Get size contract on stored, the bytes read
+// for id (at most maxSizeInBytes+1), and gunzips it if it is gzip-compressed.
+func decompress(id string, stored []byte, maxSizeInBytes int) ([]byte, error) {
+	if len(stored) > maxSizeInBytes {
+		return nil, fmt.Errorf("%w: %w: %s exceeds %d bytes", sidetree.ErrMalformed, sidetree.ErrFileTooLarge, id, maxSizeInBytes)
+	}
+	if !bytes.HasPrefix(stored, []byte{0x1f, 0x8b}) {
+		return stored, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", sidetree.ErrMalformed, id, err)
+	}
+	limit := maxSizeInBytes * sidetree.MaxMemoryDecompressionFactor
+	data, err := io.ReadAll(io.LimitReader(zr, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", sidetree.ErrMalformed, id, err)
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("%w: %w: %s decompresses past %d bytes", sidetree.ErrMalformed, sidetree.ErrFileTooLarge, id, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,96 @@
+package localcas
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sidetree "github.com/13x-tech/sidetree-go"
+)
+
+func TestCID(t *testing.T) {
+	// The CIDv1 IPFS reports for an empty raw block.
+	const want = "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"
+	if got := CID(nil); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	cas := NewDir(dir)
+	if err := cas.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	data := []byte(`{"operations":{}}`)
+	id, err := cas.Put(data)
+	if err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, id))
+	if err != nil {
+		t.Fatalf("failed to read stored object: %v", err)
+	}
+	if id != CID(stored) {
+		t.Errorf("expected the id to be the CID of the stored bytes, got %s", id)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "plain.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "corrupt"), []byte{0x1f, 0x8b, 0x00}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bomb, err := sidetree.CompressFile(bytes.Repeat([]byte{' '}, 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bomb"), bomb, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		id      string
+		max     int
+		want    []byte
+		wantErr []error
+	}{
+		"gzipped object": {id: id, max: 1000, want: data},
+		"plain object":   {id: "plain.json", max: 1000, want: data},
+		"missing":        {id: "missing", max: 1000, wantErr: []error{sidetree.ErrURINotFound}},
+		"path traversal": {id: "../" + filepath.Base(dir), max: 1000, wantErr: []error{sidetree.ErrURINotFound}},
+		"stored size over the cap": {
+			id:      "plain.json",
+			max:     len(data) - 1,
+			wantErr: []error{sidetree.ErrMalformed, sidetree.ErrFileTooLarge},
+		},
+		"decompressed size over the cap": {
+			id:      "bomb",
+			max:     len(bomb) + 1,
+			wantErr: []error{sidetree.ErrMalformed, sidetree.ErrFileTooLarge},
+		},
+		"corrupt gzip": {id: "corrupt", max: 1000, wantErr: []error{sidetree.ErrMalformed}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := cas.Get(test.id, test.max)
+			for _, wantErr := range test.wantErr {
+				if !errors.Is(err, wantErr) {
+					t.Errorf("expected %v, got %v", wantErr, err)
+				}
+			}
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if !bytes.Equal(got, test.want) {
+					t.Errorf("expected %s, got %s", test.want, got)
+				}
+			}
+		})
+	}
+}
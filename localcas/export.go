@@ -0,0 +1,76 @@
+package localcas
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	sidetree "github.com/13x-tech/sidetree-go"
+)
+
+// ExportCAR writes every file anchor references to w as a CARv1 archive
+// rooted at its core index file. The files are found by processing the anchor
+// against cas with opts, so a batch the processor rejects as malformed is
+// exported up to the file that failed; a file missing from cas fails the
+// export with an error wrapping sidetree.ErrContentUnavailable, since the
+// archive could not replay the anchor.
+//
+// A file whose URI is not the CID of its bytes, as for a real ION file named
+// by the CIDv0 of its dag-pb UnixFS node, is mapped to a raw block (see
+// WriteCAR). When cas is a Dir or a CAR the blocks are the objects as stored;
+// from any other CAS they are the decompressed files.
+func ExportCAR(w io.Writer, anchor operations.Anchor, cas sidetree.CAS, opts ...sidetree.SideTreeOption) error {
+	recorder := &fetchRecorder{CAS: cas}
+	opts = append(opts, sidetree.WithCAS(recorder))
+
+	p, err := sidetree.Processor(anchor, opts...)
+	if err != nil {
+		return err
+	}
+	processed := p.Process()
+	if errors.Is(processed.Error, sidetree.ErrContentUnavailable) {
+		return fmt.Errorf("failed to export %s: %w", anchor.Anchor, processed.Error)
+	}
+	if len(recorder.fetched) == 0 {
+		return fmt.Errorf("failed to export %s: no file was fetched", anchor.Anchor)
+	}
+
+	blocks := make([]Block, 0, len(recorder.fetched))
+	for _, f := range recorder.fetched {
+		data := f.data
+		if stored, ok := cas.(storedGetter); ok {
+			if data, err = stored.getStored(f.uri, f.maxSizeInBytes); err != nil {
+				return err
+			}
+		}
+		blocks = append(blocks, Block{URI: f.uri, Data: data})
+	}
+	return WriteCAR(w, []string{recorder.fetched[0].uri}, blocks)
+}
+
+// storedGetter is implemented by the CAS types of this package, which can
+// return an object as stored rather than decompressed.
+type storedGetter interface {
+	getStored(id string, maxSizeInBytes int) ([]byte, error)
+}
+
+type fetchedFile struct {
+	uri            string
+	maxSizeInBytes int
+	data           []byte
+}
+
+// fetchRecorder wraps a CAS to keep every file it returns, in fetch order.
+type fetchRecorder struct {
+	sidetree.CAS
+	fetched []fetchedFile
+}
+
+func (r *fetchRecorder) Get(id string, maxSizeInBytes int) ([]byte, error) {
+	data, err := r.CAS.Get(id, maxSizeInBytes)
+	if err == nil {
+		r.fetched = append(r.fetched, fetchedFile{uri: id, maxSizeInBytes: maxSizeInBytes, data: data})
+	}
+	return data, err
+}
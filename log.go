@@ -0,0 +1,59 @@
+package sidetree
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// WithLogger sets the structured logger the processor reports to. Every event
+// carries the anchor string and sequence; file events add the file name and
+// URI. File fetches, cap checks and fee decisions are logged at Debug, a
+// rejected batch at Warn (malformed) or Info (content unavailable, to be
+// retried). A BatchWriter logs each anchored batch at Info, each dropped
+// operation at Warn and each failed write at Error. Without a logger nothing
+// is logged.
+func WithLogger(logger *slog.Logger) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.log = logger
+		case *OperationsProcessor:
+			t.log = logger
+		case *BatchWriter:
+			t.log = logger
+		}
+	}
+}
+
+// discardLogger drops every record without formatting it.
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// logger returns the processor's logger with the anchor attributes attached,
+// or a discarding logger when none is configured. It is nil-safe.
+func (d *OperationsProcessor) logger() *slog.Logger {
+	if d == nil || d.log == nil {
+		return discardLogger
+	}
+	if d.anchorLog == nil {
+		d.anchorLog = d.log.With("anchor", d.Anchor(), "sequence", d.SystemAnchor())
+	}
+	return d.anchorLog
+}
+
+// logRejection logs err, which rejected the whole batch, at a level matching
+// its class.
+func (d *OperationsProcessor) logRejection(err error) {
+	if errors.Is(err, ErrContentUnavailable) {
+		d.logger().Info("anchor content unavailable", "error", err)
+		return
+	}
+	d.logger().Warn("anchor rejected", "error", err)
+}
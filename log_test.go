@@ -0,0 +1,129 @@
+package sidetree
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// logRecords decodes every JSON log line in buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]interface{}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("failed to decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestProcessorLogging(t *testing.T) {
+	cas := NewTestCAS()
+	b, err := json.Marshal(CoreIndexFile{
+		CoreProofURI: "core-proof-uri",
+		Operations:   CoreOperations{Deactivate: []Operation{{DIDSuffix: "deactivate-did"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal core index: %v", err)
+	}
+	cas.insertObject(coreIndexCID, b)
+	b, err = json.Marshal(CoreProofFile{Operations: CoreProofOperations{
+		Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal core proof: %v", err)
+	}
+	cas.insertObject("core-proof-uri", b)
+	cas.insertObject(malformedCID, []byte("not json"))
+
+	tests := map[string]struct {
+		anchor     operations.Anchor
+		wantEvents map[string]string // message -> level
+		wantURIs   []string
+	}{
+		"processed": {
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID, Sequence: "42"},
+			wantEvents: map[string]string{
+				"sidetree file fetched":                 "DEBUG",
+				"sidetree file size check passed":       "DEBUG",
+				"operation limit check passed":          "DEBUG",
+				"anchored operation count check passed": "DEBUG",
+				"processing core index file":            "DEBUG",
+				"processing core proof file":            "DEBUG",
+				"anchor processed":                      "DEBUG",
+			},
+			wantURIs: []string{coreIndexCID, "core-proof-uri"},
+		},
+		"malformed": {
+			anchor:     operations.Anchor{Anchor: "1." + malformedCID, Sequence: "42"},
+			wantEvents: map[string]string{"anchor rejected": "WARN"},
+			wantURIs:   []string{malformedCID},
+		},
+		"unavailable": {
+			anchor: operations.Anchor{Anchor: "1." + missingCID, Sequence: "42"},
+			wantEvents: map[string]string{
+				"sidetree file fetch failed": "DEBUG",
+				"anchor content unavailable": "INFO",
+			},
+			wantURIs: []string{missingCID},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			p, err := Processor(test.anchor, WithCAS(cas), WithPrefix("test"), WithLogger(logger))
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+			p.Process()
+
+			records := logRecords(t, &buf)
+			events := map[string]string{}
+			uris := map[string]struct{}{}
+			for _, record := range records {
+				if record["anchor"] != string(test.anchor.Anchor) || record["sequence"] != "42" {
+					t.Errorf("record %v is missing the anchor attributes", record)
+				}
+				events[record["msg"].(string)] = record["level"].(string)
+				if uri, ok := record["uri"].(string); ok {
+					uris[uri] = struct{}{}
+				}
+			}
+			for msg, level := range test.wantEvents {
+				if events[msg] != level {
+					t.Errorf("expected %q at %s, got %q in %v", msg, level, events[msg], events)
+				}
+			}
+			for _, uri := range test.wantURIs {
+				if _, ok := uris[uri]; !ok {
+					t.Errorf("expected a record for uri %q", uri)
+				}
+			}
+		})
+	}
+}
+
+// TestSideTreeForwardsLogger verifies that a SideTree built WithLogger hands
+// the logger to every per-anchor processor.
+func TestSideTreeForwardsLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	s := New(WithPrefix("test"), WithCAS(NewTestCAS()), WithLogger(logger))
+	if _, err := s.ProcessOperations([]operations.Anchor{{Anchor: "1." + missingCID}}, nil); err != nil {
+		t.Fatalf("unexpected top-level error: %v", err)
+	}
+	if len(logRecords(t, &buf)) == 0 {
+		t.Error("expected the processor to log through the SideTree logger")
+	}
+}
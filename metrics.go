@@ -0,0 +1,311 @@
+package sidetree
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FileType identifies the kind of a Sidetree file.
+type FileType string
+
+const (
+	FileCoreIndex        FileType = "core_index"
+	FileCoreProof        FileType = "core_proof"
+	FileProvisionalIndex FileType = "provisional_index"
+	FileProvisionalProof FileType = "provisional_proof"
+	FileChunk            FileType = "chunk"
+)
+
+// description is the file name used in error and log messages.
+func (f FileType) description() string {
+	switch f {
+	case FileCoreIndex:
+		return "core index file"
+	case FileCoreProof:
+		return "core proof file"
+	case FileProvisionalIndex:
+		return "provisional index file"
+	case FileProvisionalProof:
+		return "provisional proof file"
+	case FileChunk:
+		return "chunk file"
+	default:
+		return string(f) + " file"
+	}
+}
+
+// AnchorOutcome is how the processing of one anchor ended.
+type AnchorOutcome string
+
+const (
+	AnchorOK          AnchorOutcome = "ok"
+	AnchorMalformed   AnchorOutcome = "malformed"
+	AnchorUnavailable AnchorOutcome = "unavailable"
+)
+
+// AnchorOutcomeOf classifies the result of Process by its batch error,
+// ProcessedOperations.Error.
+func AnchorOutcomeOf(err error) AnchorOutcome {
+	switch {
+	case err == nil:
+		return AnchorOK
+	case errors.Is(err, ErrContentUnavailable):
+		return AnchorUnavailable
+	default:
+		return AnchorMalformed
+	}
+}
+
+// FetchOutcome is how one CAS Get of a Sidetree file ended.
+type FetchOutcome string
+
+const (
+	FetchOK FetchOutcome = "ok"
+	// FetchMalformed: the CAS returned content, or proved it present, that
+	// is permanently invalid, such as a file over its size cap.
+	FetchMalformed   FetchOutcome = "malformed"
+	FetchUnavailable FetchOutcome = "unavailable"
+)
+
+// fetchOutcomeOf classifies the classified error of a fetch.
+func fetchOutcomeOf(err error) FetchOutcome {
+	switch {
+	case err == nil:
+		return FetchOK
+	case errors.Is(err, ErrMalformed):
+		return FetchMalformed
+	default:
+		return FetchUnavailable
+	}
+}
+
+// Metrics receives the processor's measurements. Implementations must be safe
+// for concurrent use: one Metrics is shared by every processor a SideTree
+// creates. The default records nothing; ExpvarMetrics publishes them through
+// expvar.
+type Metrics interface {
+	// AnchorProcessed is called once per Process call.
+	AnchorProcessed(outcome AnchorOutcome)
+	// FileFetched is called for every CAS Get, with its outcome, how long it
+	// took and the size of the (decompressed) file, 0 unless the outcome is
+	// FetchOK.
+	FileFetched(file FileType, outcome FetchOutcome, bytes int, latency time.Duration)
+	// OperationsResolved is called once per operation type with the number
+	// of valid operations of that type in a processed batch.
+	OperationsResolved(opType OperationType, count int)
+	// BatchRejected is called for every rejected batch, including one whose
+	// content is unavailable, with the name of the most specific sentinel
+	// the rejection matches (see RejectionReason).
+	BatchRejected(reason string)
+	// OperationRejected is called for every operation of a processed batch
+	// rejected on its own, with its reason named like BatchRejected's.
+	OperationRejected(reason string)
+}
+
+// WithMetrics sets the Metrics the processor reports to.
+func WithMetrics(metrics Metrics) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.metrics = metrics
+		case *OperationsProcessor:
+			t.metrics = metrics
+		}
+	}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) AnchorProcessed(AnchorOutcome)                          {}
+func (noopMetrics) FileFetched(FileType, FetchOutcome, int, time.Duration) {}
+func (noopMetrics) OperationsResolved(OperationType, int)                  {}
+func (noopMetrics) BatchRejected(string)                                   {}
+func (noopMetrics) OperationRejected(string)                               {}
+
+// metricsOrNoop returns the configured Metrics, or one that records nothing.
+func (d *OperationsProcessor) metricsOrNoop() Metrics {
+	if d == nil || d.metrics == nil {
+		return noopMetrics{}
+	}
+	return d.metrics
+}
+
+// rejectionReasons lists the sentinels a rejection is labelled with, most
+// specific first. The class sentinels come last so they only label errors no
+// specific sentinel matches.
+var rejectionReasons = []struct {
+	name string
+	err  error
+}{
+	{"ErrInvalidOperationCount", ErrInvalidOperationCount},
+	{"ErrInvalidAnchorString", ErrInvalidAnchorString},
+	{"ErrTooManyOperations", ErrTooManyOperations},
+	{"ErrOperationLimitExceeded", ErrOperationLimitExceeded},
+	{"ErrUnverifiableValueLock", ErrUnverifiableValueLock},
+	{"ErrOperationCountMismatch", ErrOperationCountMismatch},
+	{"ErrFileTooLarge", ErrFileTooLarge},
+	{"ErrCASURITooLong", ErrCASURITooLong},
+	{"ErrWriterLockIDTooLong", ErrWriterLockIDTooLong},
+	{"ErrDeltaTooLarge", ErrDeltaTooLarge},
+	{"ErrInvalidDIDSuffix", ErrInvalidDIDSuffix},
+	{"ErrInvalidRevealValue", ErrInvalidRevealValue},
+	{"ErrUnknownProperty", ErrUnknownProperty},
+	{"ErrDuplicateProperty", ErrDuplicateProperty},
+	{"ErrInvalidPropertyType", ErrInvalidPropertyType},
+	{"ErrTrailingData", ErrTrailingData},
+	{"ErrDuplicateOperation", ErrDuplicateOperation},
+	{"ErrNoCoreProof", ErrNoCoreProof},
+	{"ErrCoreProofCount", ErrCoreProofCount},
+	{"ErrProvisionalProofURIEmpty", ErrProvisionalProofURIEmpty},
+	{"ErrMultipleChunks", ErrMultipleChunks},
+	{"ErrProofIndexMismatch", ErrProofIndexMismatch},
+	{"ErrUpdateMappingMismatch", ErrUpdateMappingMismatch},
+	{"ErrInvalidDeltaCount", ErrInvalidDeltaCount},
+	{"ErrMissingPrerequisite", ErrMissingPrerequisite},
+	{"ErrBatchInconsistent", ErrBatchInconsistent},
+	{"ErrInvalidSignedData", ErrInvalidSignedData},
+	{"ErrInvalidSignature", ErrInvalidSignature},
+	{"ErrRevealValueMismatch", ErrRevealValueMismatch},
+	{"ErrDIDSuffixMismatch", ErrDIDSuffixMismatch},
+	{"ErrDeltaHashMismatch", ErrDeltaHashMismatch},
+	{"ErrContentUnavailable", ErrContentUnavailable},
+	{"ErrMalformed", ErrMalformed},
+}
+
+// RejectionReason returns the name of the most specific sentinel err matches,
+// for use as a low-cardinality metric label, or "other" if it matches none.
+func RejectionReason(err error) string {
+	for _, reason := range rejectionReasons {
+		if errors.Is(err, reason.err) {
+			return reason.name
+		}
+	}
+	return "other"
+}
+
+// fetchLatencyBuckets are the upper bounds of the fetch latency histogram.
+var fetchLatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// ExpvarMetrics is a Metrics backed by expvar. It implements expvar.Var, so it
+// is exported by publishing it:
+//
+//	m := sidetree.NewExpvarMetrics()
+//	expvar.Publish("sidetree", m)
+//
+// It renders as a JSON object with these members:
+//
+//	anchors               anchors processed, by AnchorOutcome
+//	bytes_fetched         bytes fetched, by FileType
+//	fetch_latency         per FileType and FetchOutcome: a cumulative
+//	                      histogram of fetch latency ("le_<bound>" buckets
+//	                      plus "le_inf"), "count" and "sum_seconds"
+//	operations            valid operations resolved, by OperationType
+//	batch_rejections      rejected batches, by sentinel name
+//	operation_rejections  operations rejected on their own, by sentinel name
+type ExpvarMetrics struct {
+	root *expvar.Map
+
+	// mu serializes the creation of latency histograms.
+	mu sync.Mutex
+
+	anchors             *expvar.Map
+	bytesFetched        *expvar.Map
+	fetchLatency        *expvar.Map
+	operations          *expvar.Map
+	batchRejections     *expvar.Map
+	operationRejections *expvar.Map
+}
+
+// NewExpvarMetrics returns an empty, unpublished ExpvarMetrics.
+func NewExpvarMetrics() *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		root:                new(expvar.Map).Init(),
+		anchors:             new(expvar.Map).Init(),
+		bytesFetched:        new(expvar.Map).Init(),
+		fetchLatency:        new(expvar.Map).Init(),
+		operations:          new(expvar.Map).Init(),
+		batchRejections:     new(expvar.Map).Init(),
+		operationRejections: new(expvar.Map).Init(),
+	}
+	m.root.Set("anchors", m.anchors)
+	m.root.Set("bytes_fetched", m.bytesFetched)
+	m.root.Set("fetch_latency", m.fetchLatency)
+	m.root.Set("operations", m.operations)
+	m.root.Set("batch_rejections", m.batchRejections)
+	m.root.Set("operation_rejections", m.operationRejections)
+	return m
+}
+
+// String returns the metrics as JSON, implementing expvar.Var.
+func (m *ExpvarMetrics) String() string {
+	return m.root.String()
+}
+
+func (m *ExpvarMetrics) AnchorProcessed(outcome AnchorOutcome) {
+	m.anchors.Add(string(outcome), 1)
+}
+
+func (m *ExpvarMetrics) FileFetched(file FileType, outcome FetchOutcome, bytes int, latency time.Duration) {
+	if outcome == FetchOK {
+		m.bytesFetched.Add(string(file), int64(bytes))
+	}
+	m.latencyHistogram(file, outcome).observe(latency)
+}
+
+func (m *ExpvarMetrics) OperationsResolved(opType OperationType, count int) {
+	m.operations.Add(string(opType), int64(count))
+}
+
+func (m *ExpvarMetrics) BatchRejected(reason string) {
+	m.batchRejections.Add(reason, 1)
+}
+
+func (m *ExpvarMetrics) OperationRejected(reason string) {
+	m.operationRejections.Add(reason, 1)
+}
+
+// latencyHistogram returns the histogram for fetches of file with outcome,
+// creating it on first use.
+func (m *ExpvarMetrics) latencyHistogram(file FileType, outcome FetchOutcome) latencyHistogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes, ok := m.fetchLatency.Get(string(file)).(*expvar.Map)
+	if !ok {
+		outcomes = new(expvar.Map).Init()
+		m.fetchLatency.Set(string(file), outcomes)
+	}
+	if h, ok := outcomes.Get(string(outcome)).(*expvar.Map); ok {
+		return latencyHistogram{h}
+	}
+	h := new(expvar.Map).Init()
+	outcomes.Set(string(outcome), h)
+	return latencyHistogram{h}
+}
+
+// latencyHistogram is a cumulative histogram stored in an expvar.Map.
+type latencyHistogram struct {
+	m *expvar.Map
+}
+
+func (h latencyHistogram) observe(latency time.Duration) {
+	for _, bound := range fetchLatencyBuckets {
+		if latency <= bound {
+			h.m.Add(fmt.Sprintf("le_%s", bound), 1)
+		}
+	}
+	h.m.Add("le_inf", 1)
+	h.m.Add("count", 1)
+	h.m.AddFloat("sum_seconds", latency.Seconds())
+}
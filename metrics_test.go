@@ -0,0 +1,171 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestRejectionReason(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want string
+	}{
+		"specific sentinel wins over its class": {
+			err:  classifyMalformed(fmt.Errorf("wrapped: %w", ErrDuplicateOperation)),
+			want: "ErrDuplicateOperation",
+		},
+		"unavailable": {
+			err:  classifyFetch(fmt.Errorf("timeout")),
+			want: "ErrContentUnavailable",
+		},
+		"malformed without a specific sentinel": {
+			err:  classifyMalformed(fmt.Errorf("per op fee is not valid")),
+			want: "ErrMalformed",
+		},
+		"operation-level sentinel": {
+			err:  fmt.Errorf("%w: bad", ErrInvalidSignature),
+			want: "ErrInvalidSignature",
+		},
+		"unknown": {
+			err:  fmt.Errorf("something else"),
+			want: "other",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := RejectionReason(test.err); got != test.want {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+// expvarSnapshot decodes the JSON rendering of m.
+func expvarSnapshot(t *testing.T, m expvar.Var) map[string]map[string]interface{} {
+	t.Helper()
+	var snapshot map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(m.String()), &snapshot); err != nil {
+		t.Fatalf("metrics are not valid JSON: %v\n%s", err, m.String())
+	}
+	return snapshot
+}
+
+func TestExpvarMetricsFetchLatency(t *testing.T) {
+	m := NewExpvarMetrics()
+	m.FileFetched(FileChunk, FetchOK, 100, 20*time.Millisecond)
+	m.FileFetched(FileChunk, FetchOK, 50, 2*time.Second)
+	m.FileFetched(FileChunk, FetchUnavailable, 0, 30*time.Second)
+
+	snapshot := expvarSnapshot(t, m)
+	if got := snapshot["bytes_fetched"]["chunk"]; got != float64(150) {
+		t.Errorf("expected 150 chunk bytes, got %v", got)
+	}
+	outcomes := snapshot["fetch_latency"]["chunk"].(map[string]interface{})
+	want := map[FetchOutcome]map[string]float64{
+		FetchOK: {
+			"le_10ms":     0,
+			"le_50ms":     1,
+			"le_1s":       1,
+			"le_5s":       2,
+			"le_inf":      2,
+			"count":       2,
+			"sum_seconds": 2.02,
+		},
+		FetchUnavailable: {
+			"le_5s":       0,
+			"le_inf":      1,
+			"count":       1,
+			"sum_seconds": 30,
+		},
+	}
+	if len(outcomes) != len(want) {
+		t.Errorf("expected chunk latencies for %d outcomes, got %v", len(want), outcomes)
+	}
+	for outcome, values := range want {
+		latency, _ := outcomes[string(outcome)].(map[string]interface{})
+		for key, value := range values {
+			got, _ := latency[key].(float64)
+			if got != value {
+				t.Errorf("expected %s %s = %v, got %v", outcome, key, value, latency[key])
+			}
+		}
+	}
+}
+
+func TestExpvarMetricsRejections(t *testing.T) {
+	m := NewExpvarMetrics()
+	m.BatchRejected("ErrMalformed")
+	m.OperationRejected("ErrInvalidSignature")
+	m.OperationRejected("ErrInvalidSignature")
+
+	snapshot := expvarSnapshot(t, m)
+	if got := snapshot["batch_rejections"]; len(got) != 1 || got["ErrMalformed"] != float64(1) {
+		t.Errorf("expected one ErrMalformed batch rejection, got %v", got)
+	}
+	if got := snapshot["operation_rejections"]; len(got) != 1 || got["ErrInvalidSignature"] != float64(2) {
+		t.Errorf("expected two ErrInvalidSignature operation rejections, got %v", got)
+	}
+}
+
+// TestProcessorMetrics runs a valid, a malformed and an unavailable anchor
+// through a SideTree sharing one ExpvarMetrics.
+func TestProcessorMetrics(t *testing.T) {
+	cas := NewTestCAS()
+	b, err := json.Marshal(CoreIndexFile{
+		CoreProofURI: "core-proof-uri",
+		Operations:   CoreOperations{Deactivate: []Operation{{DIDSuffix: "deactivate-did"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal core index: %v", err)
+	}
+	cas.insertObject(coreIndexCID, b)
+	proof, err := json.Marshal(CoreProofFile{Operations: CoreProofOperations{
+		Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal core proof: %v", err)
+	}
+	cas.insertObject("core-proof-uri", proof)
+	cas.insertObject(malformedCID, []byte(`{"operations":{},"unexpected":1}`))
+
+	m := NewExpvarMetrics()
+	s := New(WithPrefix("test"), WithCAS(cas), WithMetrics(m))
+	anchors := []operations.Anchor{{Anchor: "1." + coreIndexCID}, {Anchor: "1." + malformedCID}, {Anchor: "1." + missingCID}}
+	if _, err := s.ProcessOperations(anchors, nil); err != nil {
+		t.Fatalf("unexpected top-level error: %v", err)
+	}
+
+	snapshot := expvarSnapshot(t, m)
+	want := map[string]map[string]float64{
+		"anchors":              {"ok": 1, "malformed": 1, "unavailable": 1},
+		"bytes_fetched":        {"core_index": float64(len(b) + len(`{"operations":{},"unexpected":1}`)), "core_proof": float64(len(proof))},
+		"operations":           {"create": 0, "recover": 0, "update": 0, "deactivate": 1},
+		"batch_rejections":     {"ErrUnknownProperty": 1, "ErrContentUnavailable": 1},
+		"operation_rejections": {},
+	}
+	for group, values := range want {
+		if len(snapshot[group]) != len(values) {
+			t.Errorf("expected %s to be %v, got %v", group, values, snapshot[group])
+		}
+		for key, value := range values {
+			if got := snapshot[group][key]; got != value {
+				t.Errorf("expected %s[%s] = %v, got %v", group, key, value, got)
+			}
+		}
+	}
+	// Every attempt is timed: the valid and the malformed core index were
+	// fetched, the missing one was not.
+	outcomes, _ := snapshot["fetch_latency"]["core_index"].(map[string]interface{})
+	for outcome, count := range map[FetchOutcome]float64{FetchOK: 2, FetchUnavailable: 1} {
+		latency, _ := outcomes[string(outcome)].(map[string]interface{})
+		if latency["count"] != count {
+			t.Errorf("expected %v %s core index fetch latencies, got %v", count, outcome, outcomes)
+		}
+	}
+}
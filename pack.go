@@ -0,0 +1,264 @@
+package sidetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// PackOption configures PackBatches.
+type PackOption func(p *packer)
+
+// WithPackValueLock lets a batch carry up to maxOperations operations, paid
+// for by the value lock writerLockID. maxOperations is capped at
+// MaxOperationsPerBatch. Without a value lock a batch carries at most
+// MaxNumberOfOperationsForNoValueTimeLock operations.
+func WithPackValueLock(writerLockID string, maxOperations int) PackOption {
+	return func(p *packer) {
+		p.writerLockID = writerLockID
+		p.maxOperations = min(maxOperations, MaxOperationsPerBatch)
+	}
+}
+
+// Packing is the result of PackBatches.
+type Packing struct {
+	// Batches are the batches to anchor, in this order. Every operation of the
+	// queue that was not rejected is in exactly one batch, and the operations
+	// for a DID suffix are spread over the batches in queue order.
+	Batches []*Batch
+
+	// Rejected maps the queue index of each operation no batch can carry to
+	// the reason: an unsupported operation type, a delta over
+	// MaxDeltaSizeInBytes, or an operation whose entries alone exceed a file
+	// cap.
+	Rejected map[int]error
+}
+
+// PackBatches splits a queue of operations, of the types NewBatch accepts,
+// into as few batches as filling each one in queue order allows. A batch
+// takes every pending operation that still fits: one whose DID suffix is not
+// already in the batch or held back for a later one, that keeps the batch
+// within its operation limit, and that keeps every file within its size cap.
+// Operations that can never fit are rejected before packing starts.
+//
+// File sizes are bounded, not measured: each URI a file will hold is counted
+// at MaxCASURILength, so a batch that fits here fits whatever URIs the CAS
+// returns.
+func PackBatches(queue []interface{}, opts ...PackOption) (*Packing, error) {
+	p := &packer{maxOperations: MaxNumberOfOperationsForNoValueTimeLock}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.writerLockID == "" {
+		p.maxOperations = min(p.maxOperations, MaxNumberOfOperationsForNoValueTimeLock)
+	}
+	if p.maxOperations < 1 {
+		return nil, fmt.Errorf("%w: a batch must allow at least one operation, got %d", ErrInvalidOperationCount, p.maxOperations)
+	}
+	if err := checkWriterLockID(p.writerLockID); err != nil {
+		return nil, err
+	}
+	if err := p.measureOverhead(); err != nil {
+		return nil, err
+	}
+
+	packing := &Packing{Rejected: map[int]error{}}
+	var pending []*packedOperation
+	for i, op := range queue {
+		packed, err := p.measure(op)
+		if err == nil && !p.newBatch().fits(packed) {
+			err = fmt.Errorf("%w: the operation alone exceeds a file size cap", ErrFileTooLarge)
+		}
+		if err != nil {
+			packing.Rejected[i] = err
+			continue
+		}
+		pending = append(pending, packed)
+	}
+
+	for len(pending) > 0 {
+		batch := p.newBatch()
+		// held holds the suffixes in the batch and those of operations held
+		// back for a later batch, which later operations must follow.
+		held := map[string]struct{}{}
+		var next []*packedOperation
+		for _, op := range pending {
+			if _, ok := held[op.suffix]; ok || !batch.fits(op) {
+				held[op.suffix] = struct{}{}
+				next = append(next, op)
+				continue
+			}
+			held[op.suffix] = struct{}{}
+			batch.add(op)
+		}
+
+		b, err := p.build(batch)
+		if err != nil {
+			return nil, err
+		}
+		packing.Batches = append(packing.Batches, b)
+		pending = next
+	}
+
+	return packing, nil
+}
+
+// packFiles are the files a batch may write, in the order packer sizes index
+// them.
+var packFiles = []FileType{FileCoreIndex, FileCoreProof, FileProvisionalIndex, FileProvisionalProof, FileChunk}
+
+// packCaps are the size caps of packFiles.
+var packCaps = []int{MaxCoreIndexFileSizeInBytes, MaxProofFileSizeInBytes, MaxProvisionalIndexFileSizeInBytes, MaxProofFileSizeInBytes, MaxChunkFileSizeInBytes}
+
+type packer struct {
+	writerLockID  string
+	maxOperations int
+
+	// overhead bounds the bytes each file encodes to besides its operation
+	// entries, indexed like packFiles.
+	overhead []int
+}
+
+// packedOperation is a queued operation with the encoded size of the entry it
+// adds to each file, indexed like packFiles (0 for no entry).
+type packedOperation struct {
+	op     interface{}
+	suffix string
+	sizes  []int
+}
+
+// packedBatch is a batch being filled, with the bounded size of each file.
+type packedBatch struct {
+	p     *packer
+	ops   []interface{}
+	sizes []int
+}
+
+func (p *packer) newBatch() *packedBatch {
+	return &packedBatch{p: p, sizes: append([]int(nil), p.overhead...)}
+}
+
+// fits reports whether op can join the batch. Each entry is counted with the
+// comma that separates it from the previous one.
+func (b *packedBatch) fits(op *packedOperation) bool {
+	if len(b.ops)+1 > b.p.maxOperations {
+		return false
+	}
+	for i, size := range op.sizes {
+		if size > 0 && b.sizes[i]+size+1 > packCaps[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *packedBatch) add(op *packedOperation) {
+	b.ops = append(b.ops, op.op)
+	for i, size := range op.sizes {
+		if size > 0 {
+			b.sizes[i] += size + 1
+		}
+	}
+}
+
+// build turns a filled batch into a Batch, with the value lock only if it
+// needs one.
+func (p *packer) build(b *packedBatch) (*Batch, error) {
+	var opts []BatchOption
+	if len(b.ops) > MaxNumberOfOperationsForNoValueTimeLock {
+		opts = append(opts, WithBatchWriterLockID(p.writerLockID))
+	}
+	return NewBatch(b.ops, opts...)
+}
+
+// measure checks op on its own and sizes its file entries.
+func (p *packer) measure(op interface{}) (*packedOperation, error) {
+	_, suffix, delta, err := describeOperation(op)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOperationDelta(delta); err != nil {
+		return nil, err
+	}
+
+	var entries []interface{}
+	switch op := op.(type) {
+	case *operations.Create:
+		entries = []interface{}{createOperationWire{SuffixData: suffixDataWireOf(op.SuffixData)}, nil, nil, nil, op.Delta}
+	case *operations.Recover:
+		entries = []interface{}{Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue}, SignedRecoverDataOp{SignedData: op.SignedData}, nil, nil, op.Delta}
+	case *operations.Update:
+		entries = []interface{}{nil, nil, Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue}, SignedUpdateDataOp{SignedData: op.SignedData}, op.Delta}
+	case *operations.Deactivate:
+		entries = []interface{}{Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue}, SignedDeactivateDataOp{SignedData: op.SignedData}, nil, nil, nil}
+	}
+
+	sizes := make([]int, len(packFiles))
+	for i, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		if sizes[i], err = entrySize(packFiles[i], entry); err != nil {
+			return nil, err
+		}
+	}
+	return &packedOperation{op: op, suffix: suffix, sizes: sizes}, nil
+}
+
+// measureOverhead encodes each file with every property present, every URI at
+// MaxCASURILength and one zero entry per operation array, and subtracts the
+// entries.
+func (p *packer) measureOverhead() error {
+	uri := strings.Repeat("u", MaxCASURILength)
+	files := []fileEncoder{
+		&CoreIndexFile{
+			WriterLockId:        p.writerLockID,
+			ProvisionalIndexURI: uri,
+			CoreProofURI:        uri,
+			Operations:          CoreOperations{Create: []CreateOperation{{}}, Recover: []Operation{{}}, Deactivate: []Operation{{}}},
+		},
+		&CoreProofFile{Operations: CoreProofOperations{Recover: []SignedRecoverDataOp{{}}, Deactivate: []SignedDeactivateDataOp{{}}}},
+		&ProvisionalIndexFile{
+			ProvisionalProofURI: uri,
+			Operations:          ProvOPS{Update: []Operation{{}}},
+			Chunks:              []ProvChunk{{ChunkFileURI: uri}},
+		},
+		&ProvisionalProofFile{Operations: ProvProofOperations{Update: []SignedUpdateDataOp{{}}}},
+		&ChunkFile{Deltas: []did.Delta{{}}},
+	}
+	entries := [][]interface{}{
+		{createOperationWire{}, Operation{}, Operation{}},
+		{SignedRecoverDataOp{}, SignedDeactivateDataOp{}},
+		{Operation{}},
+		{SignedUpdateDataOp{}},
+		{did.Delta{}},
+	}
+
+	p.overhead = make([]int, len(files))
+	for i, f := range files {
+		data, err := f.Encode()
+		if err != nil {
+			return err
+		}
+		p.overhead[i] = len(data)
+		for _, entry := range entries[i] {
+			size, err := entrySize(packFiles[i], entry)
+			if err != nil {
+				return err
+			}
+			p.overhead[i] -= size
+		}
+	}
+	return nil
+}
+
+// entrySize returns the encoded size of an operation entry of file.
+func entrySize(file FileType, entry interface{}) (int, error) {
+	data, err := encodeFile(file, entry)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
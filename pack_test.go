@@ -0,0 +1,163 @@
+package sidetree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestPackBatches(t *testing.T) {
+	deactivate := func(suffix string, signedData string) *operations.Deactivate {
+		return operations.DeactivateOperation(suffix, "reveal", signedData)
+	}
+	deactivates := func(n int) []interface{} {
+		ops := make([]interface{}, n)
+		for i := range ops {
+			ops[i] = deactivate(fmt.Sprintf("did-%d", i), "jws")
+		}
+		return ops
+	}
+	update := func(suffix string, commitment string) *operations.Update {
+		op := operations.UpdateOperation(suffix, "reveal", "jws")
+		op.SetDelta(did.Delta{UpdateCommitment: commitment})
+		return op
+	}
+	// Nine of these fill a core proof file.
+	largeProof := strings.Repeat("s", MaxProofFileSizeInBytes/9-100)
+
+	tests := map[string]struct {
+		queue []interface{}
+		opts  []PackOption
+		// want lists the DID suffixes of each batch, in order.
+		want        [][]string
+		wantLockIDs []string
+	}{
+		"under the free limit": {
+			queue: deactivates(3),
+			want:  [][]string{{"did-0", "did-1", "did-2"}},
+		},
+		"split at the free limit": {
+			queue: deactivates(MaxNumberOfOperationsForNoValueTimeLock + 1),
+			want: [][]string{
+				suffixRange(0, MaxNumberOfOperationsForNoValueTimeLock),
+				{fmt.Sprintf("did-%d", MaxNumberOfOperationsForNoValueTimeLock)},
+			},
+		},
+		"value lock allows more": {
+			queue:       deactivates(MaxNumberOfOperationsForNoValueTimeLock + 1),
+			opts:        []PackOption{WithPackValueLock("lock", 1000)},
+			want:        [][]string{suffixRange(0, MaxNumberOfOperationsForNoValueTimeLock+1)},
+			wantLockIDs: []string{"lock"},
+		},
+		"value lock only where needed": {
+			queue:       deactivates(3),
+			opts:        []PackOption{WithPackValueLock("lock", 1000)},
+			want:        [][]string{{"did-0", "did-1", "did-2"}},
+			wantLockIDs: []string{""},
+		},
+		"one operation per suffix, in queue order": {
+			queue: []interface{}{update("a", "a1"), update("b", "b1"), update("a", "a2"), update("c", "c1"), deactivate("a", "jws")},
+			want:  [][]string{{"a", "b", "c"}, {"a"}, {"a"}},
+		},
+		"split at the proof file cap": {
+			queue: func() []interface{} {
+				ops := make([]interface{}, 10)
+				for i := range ops {
+					ops[i] = deactivate(fmt.Sprintf("did-%d", i), largeProof)
+				}
+				return ops
+			}(),
+			want: [][]string{suffixRange(0, 9), {"did-9"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			packing, err := PackBatches(test.queue, test.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(packing.Rejected) != 0 {
+				t.Fatalf("expected no rejected operations, got %v", packing.Rejected)
+			}
+
+			var got [][]string
+			var lockIDs []string
+			cas := NewTestCAS()
+			for _, b := range packing.Batches {
+				got = append(got, batchSuffixes(b))
+				lockIDs = append(lockIDs, b.writerLockID)
+				if _, err := b.Write(cas); err != nil {
+					t.Errorf("expected every batch to write, got %v", err)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected batches %v, got %v", test.want, got)
+			}
+			if test.wantLockIDs != nil && !reflect.DeepEqual(lockIDs, test.wantLockIDs) {
+				t.Errorf("expected writer lock ids %q, got %q", test.wantLockIDs, lockIDs)
+			}
+		})
+	}
+}
+
+func TestPackBatchesRejects(t *testing.T) {
+	valid := operations.DeactivateOperation("valid", "reveal", "jws")
+	largeDelta := operations.UpdateOperation("large-delta", "reveal", "jws")
+	largeDelta.SetDelta(did.Delta{UpdateCommitment: strings.Repeat("c", MaxDeltaSizeInBytes)})
+	largeProof := operations.DeactivateOperation("large-proof", "reveal", strings.Repeat("s", MaxProofFileSizeInBytes))
+
+	packing, err := PackBatches([]interface{}{valid, "not an operation", largeDelta, largeProof})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[int]error{1: ErrUnsupportedOperation, 2: ErrDeltaTooLarge, 3: ErrFileTooLarge}
+	if len(packing.Rejected) != len(want) {
+		t.Errorf("expected %d rejected operations, got %v", len(want), packing.Rejected)
+	}
+	for i, wantErr := range want {
+		if !errors.Is(packing.Rejected[i], wantErr) {
+			t.Errorf("expected operation %d to be rejected with %v, got %v", i, wantErr, packing.Rejected[i])
+		}
+	}
+	if len(packing.Batches) != 1 || !reflect.DeepEqual(batchSuffixes(packing.Batches[0]), []string{"valid"}) {
+		t.Errorf("expected one batch of the valid operation, got %v", packing.Batches)
+	}
+
+	if _, err := PackBatches(nil, WithPackValueLock("", 0)); !errors.Is(err, ErrInvalidOperationCount) {
+		t.Errorf("expected %v, got %v", ErrInvalidOperationCount, err)
+	}
+	if _, err := PackBatches(nil, WithPackValueLock(strings.Repeat("l", MaxWriterLockIDInBytes+1), 1000)); !errors.Is(err, ErrWriterLockIDTooLong) {
+		t.Errorf("expected %v, got %v", ErrWriterLockIDTooLong, err)
+	}
+}
+
+// batchSuffixes returns the DID suffixes of the non-create operations of b,
+// by type in the order the files hold them.
+func batchSuffixes(b *Batch) []string {
+	var suffixes []string
+	for _, op := range b.recovers {
+		suffixes = append(suffixes, op.DIDSuffix)
+	}
+	for _, op := range b.updates {
+		suffixes = append(suffixes, op.DIDSuffix)
+	}
+	for _, op := range b.deactivates {
+		suffixes = append(suffixes, op.DIDSuffix)
+	}
+	return suffixes
+}
+
+// suffixRange returns did-from up to, but not including, did-to.
+func suffixRange(from, to int) []string {
+	var suffixes []string
+	for i := from; i < to; i++ {
+		suffixes = append(suffixes, fmt.Sprintf("did-%d", i))
+	}
+	return suffixes
+}
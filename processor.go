@@ -2,6 +2,8 @@ package sidetree
 
 import (
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/operations"
 )
@@ -78,6 +80,33 @@ type OperationsProcessor struct {
 	valueLockFn ValueLocking
 
 	baseFee int
+
+	decodeMode      DecodeMode
+	multihashChecks MultihashCheck
+
+	// Opt-in signed-data verification (WithSignatureVerification).
+	// signedDeltaHashes holds the deltaHash each verified recover/update
+	// committed to, checked once the chunk file is mapped.
+	verifySignatures  bool
+	signedDeltaHashes map[string]string
+
+	// invalidOps holds the reason each individually rejected operation was
+	// rejected, keyed by DID suffix; results is the per-operation outcome
+	// built from it at the end of Process.
+	invalidOps map[string]error
+	results    map[string]OperationResult
+
+	// resolver is the DID state deactivates are checked against
+	// (WithResolver); nil skips the check.
+	resolver Resolver
+
+	metrics Metrics
+	hooks   []Hooks
+
+	// log is the configured logger (WithLogger); anchorLog is log with the
+	// anchor attributes attached, built on first use by logger().
+	log       *slog.Logger
+	anchorLog *slog.Logger
 }
 
 type ProcessedOperations struct {
@@ -88,6 +117,37 @@ type ProcessedOperations struct {
 	UpdateOps      map[string]operations.UpdateInterface
 	DeactivateOps  map[string]operations.DeactivateInterface
 	RecoverOps     map[string]operations.RecoverInterface
+
+	// TransactionNumber is the ledger position AnchorSequence names (see
+	// TransactionNumberFromSequence). If AnchorSequence does not name one,
+	// TransactionNumberError says why, wrapping ErrInvalidTransactionNumber,
+	// and TransactionNumber is 0 and must not be used: the anchor has no
+	// position to be applied at.
+	TransactionNumber      TransactionNumber
+	TransactionNumberError error
+
+	// Results holds the outcome of every anchored operation, keyed by DID
+	// suffix. An operation rejected on its own (for example by signature
+	// verification) is reported here as OperationInvalid with its reason and
+	// is absent from the op maps above, while the rest of the batch stays
+	// valid. Results is nil when Error is set: a batch-level failure
+	// invalidates every operation in it.
+	Results map[string]OperationResult
+
+	// Consistency lists the deactivates of the batch that the resolver
+	// (WithResolver) did not hold as active DIDs when the batch was
+	// processed. It is advisory: the operations stay in the batch.
+	Consistency ConsistencyReport
+}
+
+// fileOptions returns the options the processor parses each Sidetree file
+// with.
+func (d *OperationsProcessor) fileOptions() []FileOption {
+	return []FileOption{
+		WithFileDecodeMode(d.decodeMode),
+		WithFileMultihashValidation(d.multihashChecks),
+		WithFileSignatureVerification(d.verifySignatures),
+	}
 }
 
 func (b *OperationsProcessor) Anchor() string {
@@ -99,6 +159,45 @@ func (b *OperationsProcessor) SystemAnchor() string {
 }
 
 func (d *OperationsProcessor) Process() ProcessedOperations {
+	ops := ProcessedOperations{
+		Error:          nil,
+		AnchorString:   d.Anchor(),
+		AnchorSequence: d.SystemAnchor(),
+	}
+	ops.TransactionNumber, ops.TransactionNumberError = TransactionNumberFromSequence(d.op.Sequence)
+
+	d.hookAnchorStart()
+	err := d.process()
+	d.metricsOrNoop().AnchorProcessed(AnchorOutcomeOf(err))
+	if err != nil {
+		d.logRejection(err)
+		d.metricsOrNoop().BatchRejected(RejectionReason(err))
+		d.hookBatchRejected(err)
+		ops.Error = err
+		return ops
+	}
+
+	ops.CreateOps = d.CreateOps()
+	ops.RecoverOps = d.RecoverOps()
+	ops.UpdateOps = d.UpdateOps()
+	ops.DeactivateOps = d.DeactivateOps()
+	ops.Results = d.Results()
+	ops.Consistency = ConsistencyReport{Issues: d.stateConsistencyIssues()}
+	d.recordResolvedOperations()
+	d.hookOperationsResolved(ops)
+	d.logger().Debug("anchor processed",
+		"create", len(ops.CreateOps),
+		"recover", len(ops.RecoverOps),
+		"update", len(ops.UpdateOps),
+		"deactivate", len(ops.DeactivateOps),
+	)
+
+	return ops
+}
+
+// process fetches and processes every file of the batch. A non-nil error
+// rejects the whole batch and is already classified (unavailable vs malformed).
+func (d *OperationsProcessor) process() error {
 
 	d.createMappingArray = []string{}
 	d.recoveryMappingArray = []string{}
@@ -109,15 +208,20 @@ func (d *OperationsProcessor) Process() ProcessedOperations {
 	d.deactivateOps = map[string]operations.DeactivateInterface{}
 	d.recoverOps = map[string]operations.RecoverInterface{}
 
-	ops := ProcessedOperations{
-		Error:          nil,
-		AnchorString:   d.Anchor(),
-		AnchorSequence: d.SystemAnchor(),
+	d.signedDeltaHashes = map[string]string{}
+	d.invalidOps = map[string]error{}
+	d.results = nil
+
+	// The anchor string is parsed strictly before anything is fetched: a count
+	// or URI ION would not parse rejects the batch permanently.
+	anchor, err := ParseAnchorString(string(d.op.Anchor))
+	if err != nil {
+		return classifyMalformed(err)
 	}
+	declaredOps := anchor.Operations
 
 	if err := d.fetchCoreIndexFile(); err != nil {
-		ops.Error = err // already classified (unavailable vs malformed)
-		return ops
+		return err // already classified (unavailable vs malformed)
 	}
 
 	// Per-anchor operation-count enforcement (Sidetree protocol rule). This runs
@@ -126,22 +230,23 @@ func (d *OperationsProcessor) Process() ProcessedOperations {
 	// does. Rejection is permanent and never retried, so it routes through
 	// classifyMalformed. Checked here, right after the core index file is
 	// available (so writerLockId is known) and before any file downloads.
-	declaredOps := d.op.Operations()
 	if err := d.checkOperationLimit(declaredOps); err != nil {
-		ops.Error = classifyMalformed(err)
-		return ops
+		return classifyMalformed(err)
 	}
+	d.logger().Debug("operation limit check passed", "declared", declaredOps)
 
 	// https://identity.foundation/sidetree/spec/#base-fee-variable
 	if d.baseFeeFn != nil {
-		d.baseFee = d.baseFeeFn(d.op.Operations(), string(d.op.Sequence))
+		d.baseFee = d.baseFeeFn(declaredOps, string(d.op.Sequence))
+		d.logger().Debug("base fee computed", "baseFee", d.baseFee)
 	}
 
 	// https://identity.foundation/sidetree/spec/#per-operation-fee
 	if d.perOpFeeFn != nil {
-		if !d.perOpFeeFn(d.baseFee, d.op.Operations(), string(d.op.Sequence)) {
-			ops.Error = classifyMalformed(fmt.Errorf("per op fee is not valid"))
-			return ops
+		ok := d.perOpFeeFn(d.baseFee, declaredOps, string(d.op.Sequence))
+		d.logger().Debug("per operation fee decision", "baseFee", d.baseFee, "valid", ok)
+		if !ok {
+			return classifyMalformed(fmt.Errorf("per op fee is not valid"))
 		}
 	}
 
@@ -158,85 +263,212 @@ func (d *OperationsProcessor) Process() ProcessedOperations {
 	// lock verifier must size the required lock against this declared count — not
 	// against the post-parse actual count.
 	if d.valueLockFn != nil {
-		if !d.valueLockFn(d.coreIndexFile.WriterLockId, d.baseFee, d.op.Operations(), string(d.op.Sequence)) {
-			ops.Error = classifyMalformed(fmt.Errorf("value lock is not valid"))
-			return ops
+		ok := d.valueLockFn(d.coreIndexFile.WriterLockId, d.baseFee, declaredOps, string(d.op.Sequence))
+		d.logger().Debug("value lock decision", "writerLockId", d.coreIndexFile.WriterLockId, "valid", ok)
+		if !ok {
+			return classifyMalformed(fmt.Errorf("value lock is not valid"))
 		}
 	}
 
+	d.logger().Debug("processing core index file",
+		"uri", d.coreIndexFileURI,
+		"create", len(d.coreIndexFile.Operations.Create),
+		"recover", len(d.coreIndexFile.Operations.Recover),
+		"deactivate", len(d.coreIndexFile.Operations.Deactivate),
+	)
 	if err := d.coreIndexFile.Process(); err != nil {
-		ops.Error = classifyMalformed(err)
-		return ops
+		return classifyMalformed(err)
+	}
+	d.provisionalIndexFileURI = d.coreIndexFile.ProvisionalIndexURI
+	d.coreProofFileURI = d.coreIndexFile.CoreProofURI
+
+	// Files are fetched in protocol order, and the structure of each is checked
+	// before the next is requested, so that a mismatch found in an earlier file
+	// rejects the batch before a later (and for proofs and chunks, larger) file
+	// is downloaded. The core index alone bounds the create/recover/deactivate
+	// count, so check it now.
+	if err := d.checkAnchoredOperationCount(declaredOps); err != nil {
+		return classifyMalformed(err)
 	}
 
+	// The core proof depends only on the core index, and is validated before
+	// anything the provisional index references is fetched.
 	if d.coreProofFileURI != "" {
 
 		if err := d.fetchCoreProofFile(); err != nil {
-			ops.Error = err
-			return ops
+			return err
 		}
 
-		if err := d.coreProofFile.Process(); err != nil {
-			ops.Error = classifyMalformed(err)
-			return ops
+		d.logger().Debug("processing core proof file", "uri", d.coreProofFileURI)
+		if err := d.coreProofFile.Process(d.coreIndexFile); err != nil {
+			return classifyMalformed(err)
 		}
+		for id, op := range d.coreProofFile.RecoverOps() {
+			d.recoverOps[id] = op
+		}
+		for id, op := range d.coreProofFile.DeactivateOps() {
+			d.deactivateOps[id] = op
+		}
+		d.recordSignedData(&d.coreProofFile.signedDataResults)
 	}
 
+	// The provisional index carries the chunk count, the provisional proof URI
+	// requirement, the update suffixes for duplicate detection and the last
+	// term of the anchored operation count.
 	if d.provisionalIndexFileURI != "" {
 
 		if err := d.fetchProvisionalIndexFile(); err != nil {
-			ops.Error = err
-			return ops
+			return err
+		}
+
+		d.logger().Debug("processing provisional index file", "uri", d.provisionalIndexFileURI, "update", len(d.provisionalIndexFile.Operations.Update))
+		if err := d.provisionalIndexFile.Process(d.coreIndexFile); err != nil {
+			return classifyMalformed(err)
+		}
+		d.provisionalProofFileURI = d.provisionalIndexFile.ProvisionalProofURI
+		d.chunkFileURI = d.provisionalIndexFile.ChunkFileURI()
+
+		if err := d.populateDeltaMappingArray(); err != nil {
+			return classifyMalformed(fmt.Errorf("failed to populate delta mapping array: %w", err))
 		}
 
-		if err := d.provisionalIndexFile.Process(); err != nil {
-			ops.Error = classifyMalformed(err)
-			return ops
+		if err := d.checkAnchoredOperationCount(declaredOps); err != nil {
+			return classifyMalformed(err)
 		}
+	}
+
+	if d.provisionalIndexFile != nil {
 
 		if len(d.provisionalIndexFile.Operations.Update) > 0 {
 
 			if err := d.fetchProvisionalProofFile(); err != nil {
-				ops.Error = err
-				return ops
+				return err
 			}
 
-			if err := d.provisionalProofFile.Process(); err != nil {
-				ops.Error = classifyMalformed(err)
-				return ops
+			d.logger().Debug("processing provisional proof file", "uri", d.provisionalProofFileURI)
+			if err := d.provisionalProofFile.Process(d.provisionalIndexFile); err != nil {
+				return classifyMalformed(err)
 			}
+			for id, op := range d.provisionalProofFile.UpdateOps() {
+				d.updateOps[id] = op
+			}
+			d.recordSignedData(&d.provisionalProofFile.signedDataResults)
 		}
 
+		// The chunk file is the largest (MaxChunkFileSizeInBytes) and is only
+		// requested once every index and proof file has been validated.
 		if len(d.provisionalIndexFile.Chunks) > 0 {
 			if err := d.fetchChunkFile(); err != nil {
-				ops.Error = err
-				return ops
+				return err
 			}
 
 			if err := d.chunkFile.Process(); err != nil {
-				ops.Error = classifyMalformed(err)
-				return ops
+				return classifyMalformed(err)
 			}
 		}
 	}
 
-	// A writer must not understate the anchor-string operation count to slip
-	// past checkOperationLimit above while packing more operations into the
-	// anchored files. Now that every file is parsed, reject if the anchored
-	// operation count exceeds what the anchor string declared.
-	if anchored := d.anchoredOperationCount(); anchored > declaredOps {
-		ops.Error = classifyMalformed(fmt.Errorf("%w: declared %d, anchored %d", ErrOperationCountMismatch, declaredOps, anchored))
-		return ops
+	// Every file is in: cross-check the deactivate operations, which no
+	// single file's Process can see in full.
+	if issues := d.fileConsistencyIssues(); len(issues) > 0 {
+		return classifyMalformed(ConsistencyReport{Issues: issues}.Err())
 	}
 
-	return ProcessedOperations{
-		Error:          nil,
-		AnchorString:   d.Anchor(),
-		AnchorSequence: d.SystemAnchor(),
-		CreateOps:      d.CreateOps(),
-		RecoverOps:     d.RecoverOps(),
-		UpdateOps:      d.UpdateOps(),
-		DeactivateOps:  d.DeactivateOps(),
+	if d.verifySignatures {
+		d.verifyDeltaHashes()
+	}
+	d.results = d.operationResults()
+	d.removeInvalidOps()
+
+	return nil
+}
+
+// recordResolvedOperations reports the number of valid operations of each
+// type the batch resolved, after DID filtering.
+func (d *OperationsProcessor) recordResolvedOperations() {
+	metrics := d.metricsOrNoop()
+	metrics.OperationsResolved(OperationCreate, len(d.CreateOps()))
+	metrics.OperationsResolved(OperationRecover, len(d.RecoverOps()))
+	metrics.OperationsResolved(OperationUpdate, len(d.UpdateOps()))
+	metrics.OperationsResolved(OperationDeactivate, len(d.DeactivateOps()))
+}
+
+// flagInvalidOp records that the operation for id is invalid on its own. The
+// first reason recorded for an id wins.
+func (d *OperationsProcessor) flagInvalidOp(id string, err error) {
+	if d.invalidOps == nil {
+		d.invalidOps = map[string]error{}
+	}
+	if _, ok := d.invalidOps[id]; !ok {
+		d.invalidOps[id] = err
+		d.logger().Info("operation rejected", "didSuffix", id, "error", err)
+		d.metricsOrNoop().OperationRejected(RejectionReason(err))
+	}
+}
+
+// recordSignedData takes in the signed-data outcome of a processed proof file:
+// the deltaHash each verified recover/update signed, and the operations whose
+// signed data did not verify.
+func (d *OperationsProcessor) recordSignedData(results *signedDataResults) {
+	if d.signedDeltaHashes == nil {
+		d.signedDeltaHashes = map[string]string{}
+	}
+	for id, deltaHash := range results.SignedDeltaHashes() {
+		d.signedDeltaHashes[id] = deltaHash
+	}
+	invalid := results.InvalidOps()
+	for _, id := range sortedKeys(invalid) {
+		d.flagInvalidOp(id, invalid[id])
+	}
+}
+
+// verifyDeltaHashes compares each signed deltaHash with the hash of the delta
+// the chunk file maps to that operation, flagging mismatches. An operation
+// whose signed data verified but that has no anchored delta is flagged too.
+func (d *OperationsProcessor) verifyDeltaHashes() {
+	checked := map[string]struct{}{}
+
+	if d.chunkFile != nil {
+		var mappingArray []string
+		mappingArray = append(mappingArray, d.createMappingArray...)
+		mappingArray = append(mappingArray, d.recoveryMappingArray...)
+		mappingArray = append(mappingArray, d.updateMappingArray...)
+
+		// chunkFile.Process has already checked that the mapping array and the
+		// raw deltas line up.
+		for i, id := range mappingArray {
+			want, ok := d.signedDeltaHashes[id]
+			if !ok {
+				continue
+			}
+			checked[id] = struct{}{}
+
+			got, err := deltaHash(d.chunkFile.rawDeltas[i])
+			if err != nil {
+				d.flagInvalidOp(id, fmt.Errorf("%w: %w", ErrDeltaHashMismatch, err))
+				continue
+			}
+			if got != want {
+				d.flagInvalidOp(id, fmt.Errorf("%w: signed %q, delta hashes to %q", ErrDeltaHashMismatch, want, got))
+			}
+		}
+	}
+
+	for id := range d.signedDeltaHashes {
+		if _, ok := checked[id]; !ok {
+			d.flagInvalidOp(id, fmt.Errorf("%w: no delta anchored", ErrDeltaHashMismatch))
+		}
+	}
+}
+
+// removeInvalidOps drops every individually rejected operation from the op
+// maps, so the batch result only carries operations a resolver may apply.
+func (d *OperationsProcessor) removeInvalidOps() {
+	for id := range d.invalidOps {
+		delete(d.createOps, id)
+		delete(d.recoverOps, id)
+		delete(d.updateOps, id)
+		delete(d.deactivateOps, id)
 	}
 }
 
@@ -254,10 +486,9 @@ func (d *OperationsProcessor) Process() ProcessedOperations {
 //
 // The returned error is unwrapped; the caller wraps it with classifyMalformed.
 func (d *OperationsProcessor) checkOperationLimit(opCount int) error {
-	// A valid anchor declares at least one operation. AnchorString.Operations()
-	// collapses a non-numeric count to 0 (and lets a negative count through), so
-	// guard the count's validity here — otherwise a malformed "abc.cid"/"0.cid"
-	// would be waved past the quota gate as "under the free limit".
+	// A valid anchor declares at least one operation. ParseAnchorString already
+	// guarantees it; the guard keeps a non-positive count from being waved past
+	// the quota gate as "under the free limit" should a caller skip the parse.
 	if opCount < 1 {
 		return fmt.Errorf("%w: %d", ErrInvalidOperationCount, opCount)
 	}
@@ -276,6 +507,22 @@ func (d *OperationsProcessor) checkOperationLimit(opCount int) error {
 	return nil
 }
 
+// checkAnchoredOperationCount rejects a batch whose anchored files contain more
+// operations than the anchor string declared. A writer must not understate the
+// anchor-string count to slip past checkOperationLimit while packing more
+// operations into the files. It is called once after the core index and again
+// after the provisional index, so the mismatch is caught before any proof or
+// chunk file is fetched. The returned error is unwrapped; the caller wraps it
+// with classifyMalformed.
+func (d *OperationsProcessor) checkAnchoredOperationCount(declaredOps int) error {
+	anchored := d.anchoredOperationCount()
+	if anchored > declaredOps {
+		return fmt.Errorf("%w: declared %d, anchored %d", ErrOperationCountMismatch, declaredOps, anchored)
+	}
+	d.logger().Debug("anchored operation count check passed", "declared", declaredOps, "anchored", anchored)
+	return nil
+}
+
 // anchoredOperationCount returns the number of operations actually present in
 // the anchored files: core index Create/Recover/Deactivate plus provisional
 // Update. (Provisional index may be absent.)
@@ -365,17 +612,41 @@ func checkFileSize(name string, data []byte, maxSizeInBytes int) error {
 	return nil
 }
 
-func (d *OperationsProcessor) fetchCoreIndexFile() error {
+// getFile fetches a Sidetree file from the CAS and enforces its size cap. The
+// returned error is already classified (unavailable vs malformed).
+func (d *OperationsProcessor) getFile(file FileType, uri string, maxSizeInBytes int) ([]byte, error) {
+	name := file.description()
+	log := d.logger().With("file", name, "uri", uri)
 
-	coreData, err := d.cas.Get(d.coreIndexFileURI, MaxCoreIndexFileSizeInBytes)
+	start := time.Now()
+	data, err := d.cas.Get(uri, maxSizeInBytes)
+	latency := time.Since(start)
 	if err != nil {
-		return fmt.Errorf("failed to get core index file: %w", classifyFetch(err))
+		err = classifyFetch(err)
+		log.Debug("sidetree file fetch failed", "error", err, "latency", latency)
+		d.metricsOrNoop().FileFetched(file, fetchOutcomeOf(err), 0, latency)
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	log.Debug("sidetree file fetched", "bytes", len(data), "latency", latency)
+	d.hookFileFetched(file, uri, len(data))
+	if err := checkFileSize(name, data, maxSizeInBytes); err != nil {
+		d.metricsOrNoop().FileFetched(file, fetchOutcomeOf(err), 0, latency)
+		return nil, err
 	}
-	if err := checkFileSize("core index file", coreData, MaxCoreIndexFileSizeInBytes); err != nil {
+	d.metricsOrNoop().FileFetched(file, FetchOK, len(data), latency)
+	log.Debug("sidetree file size check passed", "bytes", len(data), "limit", maxSizeInBytes*MaxMemoryDecompressionFactor)
+
+	return data, nil
+}
+
+func (d *OperationsProcessor) fetchCoreIndexFile() error {
+
+	coreData, err := d.getFile(FileCoreIndex, d.coreIndexFileURI, MaxCoreIndexFileSizeInBytes)
+	if err != nil {
 		return err
 	}
 
-	d.coreIndexFile, err = NewCoreIndexFile(d, coreData)
+	d.coreIndexFile, err = NewCoreIndexFile(coreData, d.fileOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create core index file: %w", classifyMalformed(err))
 	}
@@ -385,15 +656,12 @@ func (d *OperationsProcessor) fetchCoreIndexFile() error {
 
 func (d *OperationsProcessor) fetchCoreProofFile() error {
 
-	coreProofData, err := d.cas.Get(d.coreProofFileURI, MaxProofFileSizeInBytes)
+	coreProofData, err := d.getFile(FileCoreProof, d.coreProofFileURI, MaxProofFileSizeInBytes)
 	if err != nil {
-		return fmt.Errorf("failed to get core proof file: %w", classifyFetch(err))
-	}
-	if err := checkFileSize("core proof file", coreProofData, MaxProofFileSizeInBytes); err != nil {
 		return err
 	}
 
-	d.coreProofFile, err = NewCoreProofFile(d, coreProofData)
+	d.coreProofFile, err = NewCoreProofFile(coreProofData, d.fileOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create core proof file: %w", classifyMalformed(err))
 	}
@@ -403,15 +671,12 @@ func (d *OperationsProcessor) fetchCoreProofFile() error {
 
 func (d *OperationsProcessor) fetchProvisionalIndexFile() error {
 
-	provisionalData, err := d.cas.Get(d.provisionalIndexFileURI, MaxProvisionalIndexFileSizeInBytes)
+	provisionalData, err := d.getFile(FileProvisionalIndex, d.provisionalIndexFileURI, MaxProvisionalIndexFileSizeInBytes)
 	if err != nil {
-		return fmt.Errorf("failed to get provisional index file: %w", classifyFetch(err))
-	}
-	if err := checkFileSize("provisional index file", provisionalData, MaxProvisionalIndexFileSizeInBytes); err != nil {
 		return err
 	}
 
-	d.provisionalIndexFile, err = NewProvisionalIndexFile(d, provisionalData)
+	d.provisionalIndexFile, err = NewProvisionalIndexFile(provisionalData, d.fileOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create provisional index file: %w", classifyMalformed(err))
 	}
@@ -421,15 +686,12 @@ func (d *OperationsProcessor) fetchProvisionalIndexFile() error {
 
 func (d *OperationsProcessor) fetchProvisionalProofFile() error {
 
-	provisionalProofData, err := d.cas.Get(d.provisionalProofFileURI, MaxProofFileSizeInBytes)
+	provisionalProofData, err := d.getFile(FileProvisionalProof, d.provisionalProofFileURI, MaxProofFileSizeInBytes)
 	if err != nil {
-		return fmt.Errorf("failed to get provisional proof file: %w", classifyFetch(err))
-	}
-	if err := checkFileSize("provisional proof file", provisionalProofData, MaxProofFileSizeInBytes); err != nil {
 		return err
 	}
 
-	d.provisionalProofFile, err = NewProvisionalProofFile(d, provisionalProofData)
+	d.provisionalProofFile, err = NewProvisionalProofFile(provisionalProofData, d.fileOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create provisional proof file: %w", classifyMalformed(err))
 	}
@@ -439,17 +701,16 @@ func (d *OperationsProcessor) fetchProvisionalProofFile() error {
 
 func (d *OperationsProcessor) fetchChunkFile() error {
 
-	chunkData, err := d.cas.Get(d.chunkFileURI, MaxChunkFileSizeInBytes)
+	chunkData, err := d.getFile(FileChunk, d.chunkFileURI, MaxChunkFileSizeInBytes)
 	if err != nil {
-		return fmt.Errorf("failed to get chunk file: %w", classifyFetch(err))
-	}
-	if err := checkFileSize("chunk file", chunkData, MaxChunkFileSizeInBytes); err != nil {
 		return err
 	}
 
 	d.chunkFile, err = NewChunkFile(chunkData,
 		WithMappingArrays(d.createMappingArray, d.recoveryMappingArray, d.updateMappingArray),
 		WithOperations(d.createOps, d.recoverOps, d.updateOps),
+		WithChunkDecodeMode(d.decodeMode),
+		WithChunkLogger(d.logger().With("uri", d.chunkFileURI)),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create chunk file: %w", classifyMalformed(err))
@@ -458,31 +719,28 @@ func (d *OperationsProcessor) fetchChunkFile() error {
 	return nil
 }
 
+// populateDeltaMappingArray builds the operation delta mapping array and the
+// create operations from the processed core and provisional index files.
 func (p *OperationsProcessor) populateDeltaMappingArray() error {
 
 	provisionalIndex := p.provisionalIndexFile
 	if provisionalIndex == nil {
-		return fmt.Errorf("provisional index file is nil")
+		return missingPrerequisite("delta mapping array", "the provisional index file")
 	}
-
-	for _, op := range p.coreIndexFile.Operations.Create {
-		uri, _ := op.SuffixData.URI()
-
-		createOp := operations.CreateOperation(
-			op.SuffixData,
-		)
-
-		p.createOps[uri] = createOp
-		p.createMappingArray = append(p.createMappingArray, uri)
+	if p.coreIndexFile == nil {
+		return missingPrerequisite("delta mapping array", "the core index file")
 	}
-
-	for _, op := range p.coreIndexFile.Operations.Recover {
-		p.recoveryMappingArray = append(p.recoveryMappingArray, op.DIDSuffix)
+	if p.createOps == nil {
+		p.createOps = map[string]operations.CreateInterface{}
 	}
 
-	for _, op := range provisionalIndex.Operations.Update {
-		p.updateMappingArray = append(p.updateMappingArray, op.DIDSuffix)
+	for id, op := range p.coreIndexFile.CreateOps() {
+		p.createOps[id] = op
 	}
 
+	p.createMappingArray = append(p.createMappingArray, p.coreIndexFile.CreateMappingArray()...)
+	p.recoveryMappingArray = append(p.recoveryMappingArray, p.coreIndexFile.RecoverMappingArray()...)
+	p.updateMappingArray = append(p.updateMappingArray, provisionalIndex.UpdateMappingArray()...)
+
 	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -20,21 +22,21 @@ func TestProcessorOptions(t *testing.T) {
 		want         error
 	}{
 		"test valid": {
-			anchorString: "1.abc",
+			anchorString: "1." + coreIndexCID,
 			method:       "test",
 			cas:          NewTestCAS(),
 			filterIds:    []string{"did:sidetree:test"},
 			want:         nil,
 		},
 		"empty method": {
-			anchorString: "1.abc",
+			anchorString: "1." + coreIndexCID,
 			method:       "",
 			cas:          NewTestCAS(),
 			filterIds:    []string{"did:sidetree:test"},
 			want:         ErrInvalidMethod,
 		},
 		"nil cas": {
-			anchorString: "1.abc",
+			anchorString: "1." + coreIndexCID,
 			method:       "test",
 			cas:          nil,
 			filterIds:    []string{"did:sidetree:test"},
@@ -77,7 +79,7 @@ func TestProcessorProcess(t *testing.T) {
 		cas          CAS
 	}{
 		"with fee functions": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			feeFunctions: []interface{}{
 				BaseFeeAlgorithm(func(opCount int, anchorPoint string) int { return 0 }),
 				PerOperationFee(func(baseFee int, opCount int, anchorPoint string) bool { return true }),
@@ -88,12 +90,12 @@ func TestProcessorProcess(t *testing.T) {
 			},
 			cas: func() CAS {
 				cas := NewTestCAS()
-				cas.insertObject("abc", []byte("{}"))
+				cas.insertObject(coreIndexCID, []byte("{}"))
 				return cas
 			}(),
 		},
 		"per op fee returns false": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			feeFunctions: []interface{}{
 				BaseFeeAlgorithm(func(opCount int, anchorPoint string) int { return 0 }),
 				PerOperationFee(func(baseFee int, opCount int, anchorPoint string) bool { return false }),
@@ -104,12 +106,12 @@ func TestProcessorProcess(t *testing.T) {
 			},
 			cas: func() CAS {
 				cas := NewTestCAS()
-				cas.insertObject("abc", []byte("{}"))
+				cas.insertObject(coreIndexCID, []byte("{}"))
 				return cas
 			}(),
 		},
 		"value locking returns false": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			feeFunctions: []interface{}{
 				BaseFeeAlgorithm(func(opCount int, anchorPoint string) int { return 0 }),
 				PerOperationFee(func(baseFee int, opCount int, anchorPoint string) bool { return true }),
@@ -120,41 +122,41 @@ func TestProcessorProcess(t *testing.T) {
 			},
 			cas: func() CAS {
 				cas := NewTestCAS()
-				cas.insertObject("abc", []byte("{}"))
+				cas.insertObject(coreIndexCID, []byte("{}"))
 				return cas
 			}(),
 		},
 		"index file found": {
-			anchor: operations.Anchor{Anchor: "1.cas"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: nil,
 			},
 			cas: func() CAS {
 				cas := NewTestCAS()
-				cas.insertObject("cas", []byte("{}"))
+				cas.insertObject(coreIndexCID, []byte("{}"))
 				return cas
 			}(),
 		},
 		"index file not found": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to get core index file"),
 			},
 			cas: NewTestCAS(),
 		},
 		"bad core index data": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to create core index file"),
 			},
 			cas: func() CAS {
 				cas := NewTestCAS()
-				cas.insertObject("abc", []byte("bad data"))
+				cas.insertObject(coreIndexCID, []byte("bad data"))
 				return cas
 			}(),
 		},
 		"invalid core index": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrNoCoreProof,
 			},
@@ -173,12 +175,12 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				return cas
 			}(),
 		},
 		"with core proof not found": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to get core proof file"),
 			},
@@ -198,12 +200,12 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				return cas
 			}(),
 		},
 		"bad core proof data": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to create core proof file"),
 			},
@@ -224,12 +226,12 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				return cas
 			}(),
 		},
 		"with core proof process error": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("core proof count mismatch"),
 			},
@@ -249,13 +251,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				cas.insertObject("xyz", []byte("{}"))
 				return cas
 			}(),
 		},
 		"cannot fetch provisional index": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to get provisional index file"),
 			},
@@ -270,12 +272,12 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				return cas
 			}(),
 		},
 		"bad provisional index data": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to create provisional index file"),
 			},
@@ -290,12 +292,12 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				return cas
 			}(),
 		},
 		"empty provisional index": {
-			anchor: operations.Anchor{Anchor: "1.abc"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrMultipleChunks,
 			},
@@ -311,14 +313,14 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("abc", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 				cas.insertObject("xyz", []byte("{}"))
 
 				return cas
 			}(),
 		},
 		"fetch provisional proof error": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to get provisional proof file"),
 			},
@@ -361,13 +363,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"provisional proof bad data": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to create provisional proof file"),
 			},
@@ -402,13 +404,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"provisional proof process error": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrProofIndexMismatch,
 			},
@@ -462,13 +464,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"cannot fetch chunk": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to get chunk file"),
 			},
@@ -514,13 +516,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"bad chunk data": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: fmt.Errorf("failed to create chunk file"),
 			},
@@ -567,13 +569,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"cannot process chunk": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrInvalidDeltaCount,
 			},
@@ -630,13 +632,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate create": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -706,13 +708,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate recover": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -780,13 +782,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate update": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -842,13 +844,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate deactivate": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -883,13 +885,15 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate create + update": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			// Declares the 3 anchored operations (2 creates + 1 update) so the
+			// early anchored-count check passes and duplicate detection runs.
+			anchor: operations.Anchor{Anchor: "3." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -958,13 +962,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate create + deactivate": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -1028,13 +1032,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate create + recover": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -1098,13 +1102,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate update + recover": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -1179,13 +1183,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate update + deactivate": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -1260,13 +1264,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"duplicate recover + deactivate": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: ErrDuplicateOperation,
 			},
@@ -1325,13 +1329,13 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
 		},
 		"valid provisional index": {
-			anchor: operations.Anchor{Anchor: "1.core-index-uri"},
+			anchor: operations.Anchor{Anchor: "1." + coreIndexCID},
 			want: ProcessedOperations{
 				Error: nil,
 			},
@@ -1388,7 +1392,7 @@ func TestProcessorProcess(t *testing.T) {
 				if err != nil {
 					t.Fatal("failed to marshal core index")
 				}
-				cas.insertObject("core-index-uri", ciJSON)
+				cas.insertObject(coreIndexCID, ciJSON)
 
 				return cas
 			}(),
@@ -1502,7 +1506,7 @@ func TestProcessFilterDIDs(t *testing.T) {
 		if err != nil {
 			t.Fatal("failed to marshal core index")
 		}
-		cas.insertObject("core-index-uri", ciJSON)
+		cas.insertObject(coreIndexCID, ciJSON)
 
 		return cas
 	}()
@@ -1544,7 +1548,7 @@ func TestProcessFilterDIDs(t *testing.T) {
 				// Declares 4 operations: 1 create + 1 recover + 1 deactivate
 				// (core) + 1 update (provisional), matching the fixture so the
 				// anchored-count cross-check passes.
-				operations.Anchor{Anchor: "4.core-index-uri"},
+				operations.Anchor{Anchor: "4." + coreIndexCID},
 				WithCAS(test.cas),
 				WithPrefix("test"),
 				WithDIDs(test.filter),
@@ -1596,7 +1600,7 @@ func TestProcessorOperationLimit(t *testing.T) {
 		wantErr   error
 	}{
 		"zero declared count rejects": {
-			anchor:    "0.cid",
+			anchor:    "0." + coreIndexCID,
 			coreIndex: CoreIndexFile{},
 			wantErr:   ErrInvalidOperationCount,
 		},
@@ -1610,40 +1614,55 @@ func TestProcessorOperationLimit(t *testing.T) {
 			coreIndex: CoreIndexFile{},
 			wantErr:   ErrInvalidOperationCount,
 		},
+		"signed declared count rejects": {
+			anchor:    "+1.cid",
+			coreIndex: CoreIndexFile{},
+			wantErr:   ErrInvalidOperationCount,
+		},
+		"zero-padded declared count rejects": {
+			anchor:    "01." + coreIndexCID,
+			coreIndex: CoreIndexFile{},
+			wantErr:   ErrInvalidOperationCount,
+		},
+		"second dot rejects": {
+			anchor:    "1.cid.extra",
+			coreIndex: CoreIndexFile{},
+			wantErr:   ErrInvalidAnchorString,
+		},
 		"at no-lock limit accepts": {
-			anchor:    "100.cid",
+			anchor:    "100." + coreIndexCID,
 			coreIndex: CoreIndexFile{},
 			wantErr:   nil,
 		},
 		"over no-lock limit without a lock rejects": {
-			anchor:    "101.cid",
+			anchor:    "101." + coreIndexCID,
 			coreIndex: CoreIndexFile{},
 			wantErr:   ErrOperationLimitExceeded,
 		},
 		"over batch max without a lock rejects": {
-			anchor:    "10001.cid",
+			anchor:    "10001." + coreIndexCID,
 			coreIndex: CoreIndexFile{},
 			wantErr:   ErrTooManyOperations,
 		},
 		"over batch max rejects even with a verified lock": {
-			anchor:    "10001.cid",
+			anchor:    "10001." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: "lock-123"},
 			valueLock: accept,
 			wantErr:   ErrTooManyOperations,
 		},
 		"over quota with a lock but no verifier rejects": {
-			anchor:    "200.cid",
+			anchor:    "200." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: "lock-123"},
 			wantErr:   ErrUnverifiableValueLock,
 		},
 		"over quota with a verifier that accepts is allowed": {
-			anchor:    "200.cid",
+			anchor:    "200." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: "lock-123"},
 			valueLock: accept,
 			wantErr:   nil,
 		},
 		"over quota with a verifier that rejects is rejected": {
-			anchor:    "200.cid",
+			anchor:    "200." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: "lock-123"},
 			valueLock: reject,
 			wantErr:   fmt.Errorf("value lock is not valid"),
@@ -1695,10 +1714,10 @@ func TestProcessorAnchoredCountExceedsDeclared(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to marshal core index: %v", err)
 	}
-	cas.insertObject("cid", b)
+	cas.insertObject(coreIndexCID, b)
 
 	// Anchor string declares only 1 operation but the file packs 101.
-	p, err := Processor(operations.Anchor{Anchor: "1.cid"}, WithCAS(cas), WithPrefix("test"))
+	p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
 	if err != nil {
 		t.Fatalf("expected no error creating processor, got %v", err)
 	}
@@ -1719,9 +1738,9 @@ func TestProcessorAnchoredCountExceedsDeclared(t *testing.T) {
 func TestProcessorRejectsOversizedFile(t *testing.T) {
 	cas := NewTestCAS()
 	oversized := make([]byte, MaxCoreIndexFileSizeInBytes*MaxMemoryDecompressionFactor+1)
-	cas.insertObject("cid", oversized)
+	cas.insertObject(coreIndexCID, oversized)
 
-	p, err := Processor(operations.Anchor{Anchor: "1.cid"}, WithCAS(cas), WithPrefix("test"))
+	p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
 	if err != nil {
 		t.Fatalf("expected no error creating processor, got %v", err)
 	}
@@ -1766,7 +1785,7 @@ func TestFetchPassesPerFileSizeCaps(t *testing.T) {
 			Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
 		},
 	})
-	insert("core-index-uri", CoreIndexFile{
+	insert(coreIndexCID, CoreIndexFile{
 		ProvisionalIndexURI: "prov-index-uri",
 		CoreProofURI:        "core-proof-uri",
 		Operations: CoreOperations{
@@ -1777,7 +1796,7 @@ func TestFetchPassesPerFileSizeCaps(t *testing.T) {
 	})
 
 	// 4 operations (create + recover + deactivate + update).
-	p, err := Processor(operations.Anchor{Anchor: "4.core-index-uri"}, WithCAS(cas), WithPrefix("test"))
+	p, err := Processor(operations.Anchor{Anchor: "4." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
 	if err != nil {
 		t.Fatalf("expected no error creating processor, got %v", err)
 	}
@@ -1786,7 +1805,7 @@ func TestFetchPassesPerFileSizeCaps(t *testing.T) {
 	}
 
 	want := map[string]int{
-		"core-index-uri": MaxCoreIndexFileSizeInBytes,
+		coreIndexCID:     MaxCoreIndexFileSizeInBytes,
 		"core-proof-uri": MaxProofFileSizeInBytes,
 		"prov-index-uri": MaxProvisionalIndexFileSizeInBytes,
 		"prov-proof-uri": MaxProofFileSizeInBytes,
@@ -1819,27 +1838,27 @@ func TestProcessorPerFieldCaps(t *testing.T) {
 		wantErr   error // nil means the batch must process cleanly
 	}{
 		"writer lock id too long": {
-			anchor:    "1.cid",
+			anchor:    "1." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: tooLongLock},
 			wantErr:   ErrWriterLockIDTooLong,
 		},
 		"writer lock id at cap accepts": {
-			anchor:    "1.cid",
+			anchor:    "1." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: atCapLock},
 			wantErr:   nil,
 		},
 		"core proof uri too long": {
-			anchor:    "1.cid",
+			anchor:    "1." + coreIndexCID,
 			coreIndex: CoreIndexFile{CoreProofURI: tooLongURI},
 			wantErr:   ErrCASURITooLong,
 		},
 		"provisional index uri too long": {
-			anchor:    "1.cid",
+			anchor:    "1." + coreIndexCID,
 			coreIndex: CoreIndexFile{ProvisionalIndexURI: tooLongURI},
 			wantErr:   ErrCASURITooLong,
 		},
 		"all fields within caps accepts": {
-			anchor:    "1.cid",
+			anchor:    "1." + coreIndexCID,
 			coreIndex: CoreIndexFile{WriterLockId: "lock"},
 			wantErr:   nil,
 		},
@@ -1916,7 +1935,7 @@ func TestProcessorAcceptsRealisticAnchor(t *testing.T) {
 			Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
 		},
 	})
-	insert("core-index-uri", CoreIndexFile{
+	insert(coreIndexCID, CoreIndexFile{
 		ProvisionalIndexURI: "prov-index-uri",
 		CoreProofURI:        "core-proof-uri",
 		Operations: CoreOperations{
@@ -1928,7 +1947,7 @@ func TestProcessorAcceptsRealisticAnchor(t *testing.T) {
 
 	// 4 operations (create + recover + deactivate + update); 3 deltas
 	// (create + recover + update).
-	p, err := Processor(operations.Anchor{Anchor: "4.core-index-uri"}, WithCAS(cas), WithPrefix("test"))
+	p, err := Processor(operations.Anchor{Anchor: "4." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
 	if err != nil {
 		t.Fatalf("expected no error creating processor, got %v", err)
 	}
@@ -1970,9 +1989,9 @@ func TestProcessorProvisionalFieldCaps(t *testing.T) {
 			if err != nil {
 				t.Fatalf("failed to marshal core index: %v", err)
 			}
-			cas.insertObject("cid", ci)
+			cas.insertObject(coreIndexCID, ci)
 
-			p, err := Processor(operations.Anchor{Anchor: "1.cid"}, WithCAS(cas), WithPrefix("test"))
+			p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
 			if err != nil {
 				t.Fatalf("expected no error creating processor, got %v", err)
 			}
@@ -2034,9 +2053,9 @@ func runDeltaSizeTest(t *testing.T, chunkJSON []byte, wantErr error) {
 	if err != nil {
 		t.Fatalf("failed to marshal core index: %v", err)
 	}
-	cas.insertObject("cid", ci)
+	cas.insertObject(coreIndexCID, ci)
 
-	p, err := Processor(operations.Anchor{Anchor: "1.cid"}, WithCAS(cas), WithPrefix("test"))
+	p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
 	if err != nil {
 		t.Fatalf("expected no error creating processor, got %v", err)
 	}
@@ -2049,3 +2068,515 @@ func runDeltaSizeTest(t *testing.T, chunkJSON []byte, wantErr error) {
 		t.Errorf("expected rejection to be ErrMalformed (permanent), got %v", got.Error)
 	}
 }
+
+// TestProcessorEarlyRejectionFetches verifies that a structural mismatch found
+// in one file rejects the batch before any later file is requested. The
+// TestCAS records every URI passed to Get, so each case pins exactly which files
+// were downloaded, and in what order.
+func TestProcessorEarlyRejectionFetches(t *testing.T) {
+	validCoreIndex := CoreIndexFile{
+		ProvisionalIndexURI: "prov-index-uri",
+		CoreProofURI:        "core-proof-uri",
+		Operations: CoreOperations{
+			Recover:    []Operation{{DIDSuffix: "recover-did"}},
+			Deactivate: []Operation{{DIDSuffix: "deactivate-did"}},
+			Create:     []CreateOperation{{SuffixData: did.SuffixData{DeltaHash: "abc123", RecoveryCommitment: "xyz789"}}},
+		},
+	}
+	validCoreProof := CoreProofFile{
+		Operations: CoreProofOperations{
+			Recover:    []SignedRecoverDataOp{{SignedData: "signed-data"}},
+			Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
+		},
+	}
+	validProvIndex := ProvisionalIndexFile{
+		ProvisionalProofURI: "prov-proof-uri",
+		Operations:          ProvOPS{Update: []Operation{{DIDSuffix: "update-did", RevealValue: "reveal-value"}}},
+		Chunks:              []ProvChunk{{ChunkFileURI: "chunk-uri"}},
+	}
+	validProvProof := ProvisionalProofFile{
+		Operations: ProvProofOperations{Update: []SignedUpdateDataOp{{SignedData: "signed-data"}}},
+	}
+	validChunk := ChunkFile{Deltas: []did.Delta{{}, {}, {}}}
+
+	tests := map[string]struct {
+		anchor      operations.AnchorString
+		coreIndex   CoreIndexFile
+		coreProof   CoreProofFile
+		provIndex   ProvisionalIndexFile
+		provProof   ProvisionalProofFile
+		wantErr     error
+		wantFetched []string
+	}{
+		"core index count exceeds declared": {
+			anchor:      "2." + coreIndexCID,
+			coreIndex:   validCoreIndex,
+			coreProof:   validCoreProof,
+			provIndex:   validProvIndex,
+			provProof:   validProvProof,
+			wantErr:     ErrOperationCountMismatch,
+			wantFetched: []string{coreIndexCID},
+		},
+		"provisional updates exceed declared": {
+			anchor:      "3." + coreIndexCID,
+			coreIndex:   validCoreIndex,
+			coreProof:   validCoreProof,
+			provIndex:   validProvIndex,
+			provProof:   validProvProof,
+			wantErr:     ErrOperationCountMismatch,
+			wantFetched: []string{coreIndexCID, "core-proof-uri", "prov-index-uri"},
+		},
+		"invalid chunk count skips provisional proof and chunk": {
+			anchor:    "4." + coreIndexCID,
+			coreIndex: validCoreIndex,
+			coreProof: validCoreProof,
+			provIndex: ProvisionalIndexFile{
+				ProvisionalProofURI: "prov-proof-uri",
+				Operations:          validProvIndex.Operations,
+				Chunks:              []ProvChunk{{ChunkFileURI: "chunk-uri"}, {ChunkFileURI: "chunk-uri"}},
+			},
+			provProof:   validProvProof,
+			wantErr:     ErrMultipleChunks,
+			wantFetched: []string{coreIndexCID, "core-proof-uri", "prov-index-uri"},
+		},
+		"core proof count mismatch skips provisional index, proof and chunk": {
+			anchor:    "4." + coreIndexCID,
+			coreIndex: validCoreIndex,
+			coreProof: CoreProofFile{Operations: CoreProofOperations{
+				Recover: []SignedRecoverDataOp{{SignedData: "signed-data"}},
+			}},
+			provIndex:   validProvIndex,
+			provProof:   validProvProof,
+			wantErr:     ErrCoreProofCount,
+			wantFetched: []string{coreIndexCID, "core-proof-uri"},
+		},
+		"provisional proof mismatch skips chunk": {
+			anchor:      "4." + coreIndexCID,
+			coreIndex:   validCoreIndex,
+			coreProof:   validCoreProof,
+			provIndex:   validProvIndex,
+			provProof:   ProvisionalProofFile{},
+			wantErr:     ErrProofIndexMismatch,
+			wantFetched: []string{coreIndexCID, "core-proof-uri", "prov-index-uri", "prov-proof-uri"},
+		},
+		"valid batch fetches every file in protocol order": {
+			anchor:      "4." + coreIndexCID,
+			coreIndex:   validCoreIndex,
+			coreProof:   validCoreProof,
+			provIndex:   validProvIndex,
+			provProof:   validProvProof,
+			wantErr:     nil,
+			wantFetched: []string{coreIndexCID, "core-proof-uri", "prov-index-uri", "prov-proof-uri", "chunk-uri"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cas := NewTestCAS()
+			insert := func(uri string, v interface{}) {
+				b, err := json.Marshal(v)
+				if err != nil {
+					t.Fatalf("failed to marshal %s: %v", uri, err)
+				}
+				cas.insertObject(uri, b)
+			}
+			insert(coreIndexCID, test.coreIndex)
+			insert("core-proof-uri", test.coreProof)
+			insert("prov-index-uri", test.provIndex)
+			insert("prov-proof-uri", test.provProof)
+			insert("chunk-uri", validChunk)
+
+			p, err := Processor(operations.Anchor{Anchor: test.anchor}, WithCAS(cas), WithPrefix("test"))
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+
+			got := p.Process()
+			if !checkError(got.Error, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, got.Error)
+			}
+			if test.wantErr != nil && !errors.Is(got.Error, ErrMalformed) {
+				t.Errorf("expected rejection to be ErrMalformed (permanent), got %v", got.Error)
+			}
+			if fetched := cas.fetchedURIs(); !slices.Equal(fetched, test.wantFetched) {
+				t.Errorf("fetched %v, want %v", fetched, test.wantFetched)
+			}
+		})
+	}
+}
+
+// TestProcessorMultihashValidation verifies the optional parse-time multihash
+// checks: with WithMultihashValidation, a recover/deactivate/update entry whose
+// suffix or reveal value is not a base64url SHA-256 multihash rejects the batch
+// permanently, while the default leaves them to apply time.
+func TestProcessorMultihashValidation(t *testing.T) {
+	valid := testMultihash(t, "value")
+	recoverSuffix := testMultihash(t, "recover")
+	deactivateSuffix := testMultihash(t, "deactivate")
+	updateSuffix := testMultihash(t, "update")
+
+	tests := map[string]struct {
+		checks     MultihashCheck
+		recover    Operation
+		deactivate Operation
+		update     Operation
+		wantErr    error
+	}{
+		"valid references pass reference checks": {
+			checks:     CheckReferenceMultihashes,
+			recover:    Operation{DIDSuffix: recoverSuffix, RevealValue: valid},
+			deactivate: Operation{DIDSuffix: deactivateSuffix, RevealValue: valid},
+			update:     Operation{DIDSuffix: updateSuffix, RevealValue: valid},
+		},
+		"invalid recover suffix": {
+			checks:     CheckReferenceMultihashes,
+			recover:    Operation{DIDSuffix: "recover-did", RevealValue: valid},
+			deactivate: Operation{DIDSuffix: deactivateSuffix, RevealValue: valid},
+			update:     Operation{DIDSuffix: updateSuffix, RevealValue: valid},
+			wantErr:    ErrInvalidDIDSuffix,
+		},
+		"invalid deactivate reveal value": {
+			checks:     CheckReferenceMultihashes,
+			recover:    Operation{DIDSuffix: recoverSuffix, RevealValue: valid},
+			deactivate: Operation{DIDSuffix: deactivateSuffix, RevealValue: "reveal-value"},
+			update:     Operation{DIDSuffix: updateSuffix, RevealValue: valid},
+			wantErr:    ErrInvalidRevealValue,
+		},
+		"invalid update suffix": {
+			checks:     CheckDIDSuffixes,
+			recover:    Operation{DIDSuffix: recoverSuffix, RevealValue: "reveal-value"},
+			deactivate: Operation{DIDSuffix: deactivateSuffix, RevealValue: "reveal-value"},
+			update:     Operation{DIDSuffix: "update-did", RevealValue: "reveal-value"},
+			wantErr:    ErrInvalidDIDSuffix,
+		},
+		"default accepts arbitrary strings": {
+			recover:    Operation{DIDSuffix: "recover-did", RevealValue: "reveal-value"},
+			deactivate: Operation{DIDSuffix: "deactivate-did", RevealValue: "reveal-value"},
+			update:     Operation{DIDSuffix: "update-did", RevealValue: "reveal-value"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cas := NewTestCAS()
+			insert := func(uri string, v interface{}) {
+				b, err := json.Marshal(v)
+				if err != nil {
+					t.Fatalf("failed to marshal %s: %v", uri, err)
+				}
+				cas.insertObject(uri, b)
+			}
+			insert("chunk-uri", ChunkFile{Deltas: []did.Delta{{}, {}, {}}})
+			insert("prov-proof-uri", ProvisionalProofFile{
+				Operations: ProvProofOperations{Update: []SignedUpdateDataOp{{SignedData: "signed-data"}}},
+			})
+			insert("prov-index-uri", ProvisionalIndexFile{
+				ProvisionalProofURI: "prov-proof-uri",
+				Operations:          ProvOPS{Update: []Operation{test.update}},
+				Chunks:              []ProvChunk{{ChunkFileURI: "chunk-uri"}},
+			})
+			insert("core-proof-uri", CoreProofFile{
+				Operations: CoreProofOperations{
+					Recover:    []SignedRecoverDataOp{{SignedData: "signed-data"}},
+					Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
+				},
+			})
+			insert(coreIndexCID, CoreIndexFile{
+				ProvisionalIndexURI: "prov-index-uri",
+				CoreProofURI:        "core-proof-uri",
+				Operations: CoreOperations{
+					Recover:    []Operation{test.recover},
+					Deactivate: []Operation{test.deactivate},
+					Create:     []CreateOperation{{SuffixData: did.SuffixData{DeltaHash: "abc123", RecoveryCommitment: "xyz789"}}},
+				},
+			})
+
+			p, err := Processor(
+				operations.Anchor{Anchor: "4." + coreIndexCID},
+				WithCAS(cas),
+				WithPrefix("test"),
+				WithMultihashValidation(test.checks),
+			)
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+
+			got := p.Process()
+			if test.wantErr == nil {
+				if got.Error != nil {
+					t.Fatalf("expected the batch to process cleanly, got %v", got.Error)
+				}
+				return
+			}
+			if !errors.Is(got.Error, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, got.Error)
+			}
+			if !errors.Is(got.Error, ErrMalformed) {
+				t.Errorf("expected rejection to be ErrMalformed (permanent), got %v", got.Error)
+			}
+		})
+	}
+}
+
+// TestProcessorSignatureVerification drives a batch of real ES256K-signed
+// recover, deactivate and update operations through Process with
+// WithSignatureVerification. Each broken operation must be reported on its own
+// in Results while the batch and its valid operations are still returned.
+func TestProcessorSignatureVerification(t *testing.T) {
+	recoverKey := newTestSigner(t, "recover")
+	deactivateKey := newTestSigner(t, "deactivate")
+	updateKey := newTestSigner(t, "update")
+	other := newTestSigner(t, "other")
+
+	createDelta := did.Delta{UpdateCommitment: "create-commitment"}
+	recoverDelta := did.Delta{UpdateCommitment: "recover-commitment"}
+	updateDelta := did.Delta{UpdateCommitment: "update-commitment"}
+
+	tests := map[string]struct {
+		recoverSigned    string
+		deactivateSigned string
+		updateSigned     string
+		chunkDeltas      []did.Delta
+		wantInvalid      map[string]error
+	}{
+		"all valid": {
+			recoverSigned:    recoverKey.signRecover(t, recoverDelta),
+			deactivateSigned: deactivateKey.signDeactivate(t, "deactivate-did"),
+			updateSigned:     updateKey.signUpdate(t, updateDelta),
+			chunkDeltas:      []did.Delta{createDelta, recoverDelta, updateDelta},
+			wantInvalid:      map[string]error{},
+		},
+		"recover signed by another key": {
+			recoverSigned:    other.sign(t, map[string]interface{}{"recoveryCommitment": "c", "recoveryKey": recoverKey.jwk, "deltaHash": testDeltaHash(t, recoverDelta)}),
+			deactivateSigned: deactivateKey.signDeactivate(t, "deactivate-did"),
+			updateSigned:     updateKey.signUpdate(t, updateDelta),
+			chunkDeltas:      []did.Delta{createDelta, recoverDelta, updateDelta},
+			wantInvalid:      map[string]error{"recover-did": ErrInvalidSignature},
+		},
+		"deactivate signed for another suffix": {
+			recoverSigned:    recoverKey.signRecover(t, recoverDelta),
+			deactivateSigned: deactivateKey.signDeactivate(t, "another-did"),
+			updateSigned:     updateKey.signUpdate(t, updateDelta),
+			chunkDeltas:      []did.Delta{createDelta, recoverDelta, updateDelta},
+			wantInvalid:      map[string]error{"deactivate-did": ErrDIDSuffixMismatch},
+		},
+		"update reveal does not match key": {
+			recoverSigned:    recoverKey.signRecover(t, recoverDelta),
+			deactivateSigned: deactivateKey.signDeactivate(t, "deactivate-did"),
+			updateSigned:     other.signUpdate(t, updateDelta),
+			chunkDeltas:      []did.Delta{createDelta, recoverDelta, updateDelta},
+			wantInvalid:      map[string]error{"update-did": ErrRevealValueMismatch},
+		},
+		"anchored deltas do not match signed hashes": {
+			recoverSigned:    recoverKey.signRecover(t, recoverDelta),
+			deactivateSigned: deactivateKey.signDeactivate(t, "deactivate-did"),
+			updateSigned:     updateKey.signUpdate(t, updateDelta),
+			chunkDeltas:      []did.Delta{createDelta, updateDelta, recoverDelta},
+			wantInvalid: map[string]error{
+				"recover-did": ErrDeltaHashMismatch,
+				"update-did":  ErrDeltaHashMismatch,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cas := NewTestCAS()
+			insert := func(uri string, v interface{}) {
+				b, err := json.Marshal(v)
+				if err != nil {
+					t.Fatalf("failed to marshal %s: %v", uri, err)
+				}
+				cas.insertObject(uri, b)
+			}
+			insert("chunk-uri", ChunkFile{Deltas: test.chunkDeltas})
+			insert("prov-proof-uri", ProvisionalProofFile{
+				Operations: ProvProofOperations{Update: []SignedUpdateDataOp{{SignedData: test.updateSigned}}},
+			})
+			insert("prov-index-uri", ProvisionalIndexFile{
+				ProvisionalProofURI: "prov-proof-uri",
+				Operations:          ProvOPS{Update: []Operation{{DIDSuffix: "update-did", RevealValue: updateKey.reveal}}},
+				Chunks:              []ProvChunk{{ChunkFileURI: "chunk-uri"}},
+			})
+			insert("core-proof-uri", CoreProofFile{
+				Operations: CoreProofOperations{
+					Recover:    []SignedRecoverDataOp{{SignedData: test.recoverSigned}},
+					Deactivate: []SignedDeactivateDataOp{{SignedData: test.deactivateSigned}},
+				},
+			})
+			insert(coreIndexCID, CoreIndexFile{
+				ProvisionalIndexURI: "prov-index-uri",
+				CoreProofURI:        "core-proof-uri",
+				Operations: CoreOperations{
+					Recover:    []Operation{{DIDSuffix: "recover-did", RevealValue: recoverKey.reveal}},
+					Deactivate: []Operation{{DIDSuffix: "deactivate-did", RevealValue: deactivateKey.reveal}},
+					Create:     []CreateOperation{{SuffixData: did.SuffixData{DeltaHash: testDeltaHash(t, createDelta), RecoveryCommitment: "xyz789"}}},
+				},
+			})
+
+			p, err := Processor(
+				operations.Anchor{Anchor: "4." + coreIndexCID},
+				WithCAS(cas),
+				WithPrefix("test"),
+				WithSignatureVerification(true),
+			)
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+
+			got := p.Process()
+			if got.Error != nil {
+				t.Fatalf("an invalid operation must not fail the batch, got %v", got.Error)
+			}
+			if len(got.Results) != 4 {
+				t.Fatalf("expected a result for each of the 4 operations, got %v", got.Results)
+			}
+			for id, result := range got.Results {
+				wantErr, wantInvalid := test.wantInvalid[id]
+				if !wantInvalid {
+					if !result.Valid() || result.Reason != nil {
+						t.Errorf("expected %s to be valid, got %s: %v", id, result.Status, result.Reason)
+					}
+					continue
+				}
+				if result.Status != OperationInvalid || !errors.Is(result.Reason, wantErr) {
+					t.Errorf("expected %s to be invalid with %v, got %s: %v", id, wantErr, result.Status, result.Reason)
+				}
+			}
+			wantTypes := map[string]OperationType{
+				"recover-did":    OperationRecover,
+				"deactivate-did": OperationDeactivate,
+				"update-did":     OperationUpdate,
+			}
+			for id, wantType := range wantTypes {
+				if got.Results[id].Type != wantType {
+					t.Errorf("expected %s to be a %s, got %q", id, wantType, got.Results[id].Type)
+				}
+			}
+
+			total := len(got.CreateOps) + len(got.RecoverOps) + len(got.DeactivateOps) + len(got.UpdateOps)
+			if total != 4-len(test.wantInvalid) {
+				t.Errorf("expected %d valid operations, got %d", 4-len(test.wantInvalid), total)
+			}
+			for id := range test.wantInvalid {
+				_, inRecover := got.RecoverOps[id]
+				_, inDeactivate := got.DeactivateOps[id]
+				_, inUpdate := got.UpdateOps[id]
+				if inRecover || inDeactivate || inUpdate {
+					t.Errorf("invalid operation %s is still returned as valid", id)
+				}
+			}
+		})
+	}
+}
+
+// TestProcessorSignatureVerificationOff verifies that verification is opt-in:
+// placeholder signed data is passed through untouched by default.
+func TestProcessorSignatureVerificationOff(t *testing.T) {
+	cas := NewTestCAS()
+	b, err := json.Marshal(CoreIndexFile{
+		CoreProofURI: "core-proof-uri",
+		Operations:   CoreOperations{Deactivate: []Operation{{DIDSuffix: "deactivate-did"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal core index: %v", err)
+	}
+	cas.insertObject(coreIndexCID, b)
+	b, err = json.Marshal(CoreProofFile{Operations: CoreProofOperations{
+		Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal core proof: %v", err)
+	}
+	cas.insertObject("core-proof-uri", b)
+
+	p, err := Processor(operations.Anchor{Anchor: "1." + coreIndexCID}, WithCAS(cas), WithPrefix("test"))
+	if err != nil {
+		t.Fatalf("expected no error creating processor, got %v", err)
+	}
+	got := p.Process()
+	if got.Error != nil {
+		t.Fatalf("expected no error, got %v", got.Error)
+	}
+	if len(got.DeactivateOps) != 1 || !got.Results["deactivate-did"].Valid() {
+		t.Errorf("expected the deactivate to pass through unverified, got %v", got.Results)
+	}
+}
+
+// TestProcessorResults verifies that every operation of a valid batch gets a
+// valid result of the right type, that Results honors the DID filter, and that
+// a batch-level failure reports no per-operation results.
+func TestProcessorResults(t *testing.T) {
+	cas := NewTestCAS()
+	insert := func(uri string, v interface{}) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", uri, err)
+		}
+		cas.insertObject(uri, b)
+	}
+	insert("core-proof-uri", CoreProofFile{Operations: CoreProofOperations{
+		Deactivate: []SignedDeactivateDataOp{{SignedData: "signed-data"}},
+	}})
+	insert(coreIndexCID, CoreIndexFile{
+		CoreProofURI: "core-proof-uri",
+		Operations: CoreOperations{
+			Deactivate: []Operation{{DIDSuffix: "deactivate-did", RevealValue: "reveal"}},
+		},
+	})
+	insert(malformedCID, CoreIndexFile{
+		Operations: CoreOperations{
+			Deactivate: []Operation{{DIDSuffix: "deactivate-did", RevealValue: "reveal"}},
+		},
+	})
+
+	tests := map[string]struct {
+		anchor      string
+		opts        []SideTreeOption
+		wantResults map[string]OperationResult
+		wantErr     error
+	}{
+		"valid batch": {
+			anchor: "1." + coreIndexCID,
+			wantResults: map[string]OperationResult{
+				"deactivate-did": {Type: OperationDeactivate, Status: OperationValid},
+			},
+		},
+		"filtered out": {
+			anchor:      "1." + coreIndexCID,
+			opts:        []SideTreeOption{WithDIDs([]string{"other-did"})},
+			wantResults: map[string]OperationResult{},
+		},
+		"batch-level failure": {
+			anchor:  "1." + malformedCID,
+			wantErr: ErrNoCoreProof,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := append([]SideTreeOption{WithCAS(cas), WithPrefix("test")}, test.opts...)
+			p, err := Processor(operations.Anchor{Anchor: operations.AnchorString(test.anchor)}, opts...)
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+
+			got := p.Process()
+			if test.wantErr != nil {
+				if !errors.Is(got.Error, test.wantErr) {
+					t.Errorf("expected %v, got %v", test.wantErr, got.Error)
+				}
+				if got.Results != nil {
+					t.Errorf("expected no results for a rejected batch, got %v", got.Results)
+				}
+				return
+			}
+			if got.Error != nil {
+				t.Fatalf("expected no error, got %v", got.Error)
+			}
+			if !reflect.DeepEqual(got.Results, test.wantResults) {
+				t.Errorf("expected results %v, got %v", test.wantResults, got.Results)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package sidetree
 
 import (
-	"encoding/json"
 	"fmt"
 )
 
@@ -10,12 +9,13 @@ var (
 	ErrMultipleChunks           = fmt.Errorf("provisional index file contains invalid chunk count")
 )
 
-func NewProvisionalIndexFile(processor *OperationsProcessor, data []byte) (*ProvisionalIndexFile, error) {
-	var p ProvisionalIndexFile
-	if err := json.Unmarshal(data, &p); err != nil {
+// NewProvisionalIndexFile decodes a provisional index file. Process validates
+// it against the batch's core index file.
+func NewProvisionalIndexFile(data []byte, opts ...FileOption) (*ProvisionalIndexFile, error) {
+	p := ProvisionalIndexFile{opts: newFileOptions(opts)}
+	if err := decodeJSON(p.opts.decodeMode, data, &p); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal provisional index file: %w", err)
 	}
-	p.processor = processor
 	return &p, nil
 }
 
@@ -24,13 +24,19 @@ type ProvisionalIndexFile struct {
 	Operations          ProvOPS     `json:"operations,omitempty"`
 	Chunks              []ProvChunk `json:"chunks"`
 
-	revealValues map[string]string
-	processor    *OperationsProcessor
-}
+	opts fileOptions
 
-func (p *ProvisionalIndexFile) Process() error {
-	// p.processor.log.Infof("Processing provisional index file %s", p.processor.ProvisionalIndexFileURI)
+	// Set by Process.
+	revealValues       map[string]string
+	updateMappingArray []string
+	chunkFileURI       string
+}
 
+// Process validates the provisional index file against coreIndex, which must
+// have been processed: the field caps, the optional multihash checks, DID
+// suffixes already used by the core index, the provisional proof URI
+// requirement and the single chunk of version 1.
+func (p *ProvisionalIndexFile) Process(coreIndex *CoreIndexFile) error {
 	// Max Provisional Index File Size is enforced at fetch time
 	// (fetchProvisionalIndexFile passes MaxProvisionalIndexFileSizeInBytes to CAS.Get).
 
@@ -45,13 +51,14 @@ func (p *ProvisionalIndexFile) Process() error {
 		}
 	}
 
-	p.processor.provisionalProofFileURI = p.ProvisionalProofURI
-
-	if err := p.processor.populateDeltaMappingArray(); err != nil {
-		return fmt.Errorf("failed to populate delta mapping array: %w", err)
+	// Optional parse-time multihash checks (WithMultihashValidation).
+	for _, op := range p.Operations.Update {
+		if err := checkOperationReference(p.opts.multihashChecks, "update", op); err != nil {
+			return err
+		}
 	}
 
-	if err := p.populateCoreOperationArray(); err != nil {
+	if err := p.populateCoreOperationArray(coreIndex); err != nil {
 		return fmt.Errorf("failed to populate core operation storage array: %w", err)
 	}
 
@@ -67,26 +74,35 @@ func (p *ProvisionalIndexFile) Process() error {
 		return fmt.Errorf("chunk file uri is empty")
 	}
 
-	p.processor.chunkFileURI = chunk.ChunkFileURI
+	p.chunkFileURI = chunk.ChunkFileURI
 
 	return nil
 }
 
 func (p *ProvisionalIndexFile) setRevealValues() {
 	p.revealValues = map[string]string{}
+	p.updateMappingArray = make([]string, 0, len(p.Operations.Update))
 	for _, op := range p.Operations.Update {
 		p.revealValues[op.DIDSuffix] = op.RevealValue
+		p.updateMappingArray = append(p.updateMappingArray, op.DIDSuffix)
 	}
 }
 
-func (p *ProvisionalIndexFile) populateCoreOperationArray() error {
+func (p *ProvisionalIndexFile) populateCoreOperationArray(coreIndex *CoreIndexFile) error {
+	// The suffix map is built when the core index file is processed.
+	if !coreIndex.processed() {
+		return missingPrerequisite("provisional index file", "a processed core index file")
+	}
 
+	seen := map[string]struct{}{}
 	for _, op := range p.Operations.Update {
-		if _, ok := p.processor.coreIndexFile.suffixMap[op.DIDSuffix]; ok {
+		if _, ok := coreIndex.suffixMap[op.DIDSuffix]; ok {
 			return ErrDuplicateOperation
 		}
-
-		p.processor.coreIndexFile.suffixMap[op.DIDSuffix] = struct{}{}
+		if _, ok := seen[op.DIDSuffix]; ok {
+			return ErrDuplicateOperation
+		}
+		seen[op.DIDSuffix] = struct{}{}
 	}
 
 	if len(p.Operations.Update) > 0 && p.ProvisionalProofURI == "" {
@@ -96,6 +112,19 @@ func (p *ProvisionalIndexFile) populateCoreOperationArray() error {
 	return nil
 }
 
+// UpdateMappingArray returns the DID suffix of each update operation, in file
+// order: the last part of the operation delta mapping array. It is nil until
+// Process succeeds.
+func (p *ProvisionalIndexFile) UpdateMappingArray() []string {
+	return p.updateMappingArray
+}
+
+// ChunkFileURI returns the URI of the batch's chunk file. It is empty until
+// Process succeeds.
+func (p *ProvisionalIndexFile) ChunkFileURI() string {
+	return p.chunkFileURI
+}
+
 type ProvOPS struct {
 	Update []Operation `json:"update"`
 }
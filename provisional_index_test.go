@@ -2,7 +2,9 @@ package sidetree
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -73,20 +75,17 @@ func TestCoreOperationsArray(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			p := &OperationsProcessor{
-				coreIndexFile: &CoreIndexFile{
-					suffixMap: test.suffixMap,
-				},
+			coreIndex := &CoreIndexFile{
+				suffixMap: test.suffixMap,
 			}
 
 			pi := ProvisionalIndexFile{
-				processor: p,
 				Operations: ProvOPS{
 					Update: test.Update,
 				},
 				ProvisionalProofURI: test.ProofURI,
 			}
-			if err := pi.populateCoreOperationArray(); err != test.want {
+			if err := pi.populateCoreOperationArray(coreIndex); err != test.want {
 				t.Errorf("got %v, want %v", err, test.want)
 			}
 		})
@@ -96,9 +95,7 @@ func TestCoreOperationsArray(t *testing.T) {
 
 func TestNewProvisionalIndexFile(t *testing.T) {
 	t.Run("bad data", func(t *testing.T) {
-		p := &OperationsProcessor{}
 		_, err := NewProvisionalIndexFile(
-			p,
 			[]byte("bad data"),
 		)
 		if !strings.Contains(err.Error(), "failed to unmarshal") {
@@ -106,9 +103,7 @@ func TestNewProvisionalIndexFile(t *testing.T) {
 		}
 	})
 	t.Run("good data", func(t *testing.T) {
-		p := &OperationsProcessor{}
 		_, err := NewProvisionalIndexFile(
-			p,
 			[]byte("{}"),
 		)
 		if err != nil {
@@ -122,22 +117,22 @@ func TestProcessProvisionalIndexFile(t *testing.T) {
 	tests := map[string]struct {
 		ProofURI   string
 		Operations ProvOPS
-		IndexFile  *ProvisionalIndexFile
+		CoreIndex  *CoreIndexFile
 		Chunks     []ProvChunk
 		want       error
 	}{
 		"multiple chunks": {
 			ProofURI:   "some-proof-uri",
 			Operations: ProvOPS{},
-			IndexFile:  &ProvisionalIndexFile{},
+			CoreIndex:  &CoreIndexFile{suffixMap: map[string]struct{}{}},
 			Chunks:     []ProvChunk{{}, {}},
 			want:       ErrMultipleChunks,
 		},
-		"does not have index file": {
+		"does not have core index file": {
 			ProofURI:   "some-proof-uri",
 			Operations: ProvOPS{},
 			Chunks:     []ProvChunk{{}},
-			want:       fmt.Errorf("provisional index file is nil"),
+			want:       ErrMissingPrerequisite,
 		},
 		"no proof uri": {
 			ProofURI: "",
@@ -146,13 +141,13 @@ func TestProcessProvisionalIndexFile(t *testing.T) {
 					DIDSuffix: "abc",
 				}},
 			},
-			IndexFile: &ProvisionalIndexFile{},
+			CoreIndex: &CoreIndexFile{suffixMap: map[string]struct{}{}},
 			Chunks:    []ProvChunk{{}},
 			want:      ErrProvisionalProofURIEmpty,
 		},
 		"no chunk file uri": {
 			ProofURI:  "some-proof-uri",
-			IndexFile: &ProvisionalIndexFile{},
+			CoreIndex: &CoreIndexFile{suffixMap: map[string]struct{}{}},
 			Operations: ProvOPS{
 				Update: []Operation{{
 					DIDSuffix: "abc",
@@ -165,7 +160,7 @@ func TestProcessProvisionalIndexFile(t *testing.T) {
 		},
 		"no error": {
 			ProofURI:  "some-proof-uri",
-			IndexFile: &ProvisionalIndexFile{},
+			CoreIndex: &CoreIndexFile{suffixMap: map[string]struct{}{}},
 			Operations: ProvOPS{
 				Update: []Operation{{
 					DIDSuffix: "abc",
@@ -180,12 +175,6 @@ func TestProcessProvisionalIndexFile(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			p := &OperationsProcessor{
-				coreIndexFile: &CoreIndexFile{
-					suffixMap: map[string]struct{}{},
-				},
-				provisionalIndexFile: test.IndexFile,
-			}
 			jsonData, err := json.Marshal(ProvisionalIndexFile{
 				ProvisionalProofURI: test.ProofURI,
 				Operations:          test.Operations,
@@ -195,14 +184,95 @@ func TestProcessProvisionalIndexFile(t *testing.T) {
 				t.Fatalf("json marshal error got %v, want no error", err)
 			}
 
-			pi, err := NewProvisionalIndexFile(p, jsonData)
+			pi, err := NewProvisionalIndexFile(jsonData)
 			if err != nil {
 				t.Fatalf("new provisional index error got %v, want no error", err)
 			}
-			if err := pi.Process(); !checkError(err, test.want) {
+			if err := pi.Process(test.CoreIndex); !checkError(err, test.want) {
 				t.Errorf("process error got %v, want %v", err, test.want)
 			}
 
 		})
 	}
 }
+
+func TestProvisionalIndexProcessMissingPrerequisites(t *testing.T) {
+	data := []byte(`{"provisionalProofFileUri":"proof","chunks":[{"chunkFileUri":"chunk"}],"operations":{"update":[{"didSuffix":"update-did","revealValue":"r"}]}}`)
+
+	tests := map[string]struct {
+		coreIndex *CoreIndexFile
+		want      error
+	}{
+		"no core index":          {want: ErrMissingPrerequisite},
+		"unprocessed core index": {coreIndex: &CoreIndexFile{}, want: ErrMissingPrerequisite},
+		"processed core index":   {coreIndex: &CoreIndexFile{suffixMap: map[string]struct{}{}}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := NewProvisionalIndexFile(data)
+			if err != nil {
+				t.Fatalf("expected no error decoding, got %v", err)
+			}
+			err = f.Process(test.coreIndex)
+			if test.want != nil {
+				if !errors.Is(err, test.want) {
+					t.Errorf("expected %v, got %v", test.want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := f.UpdateMappingArray(); !reflect.DeepEqual(got, []string{"update-did"}) {
+				t.Errorf("expected update mapping [update-did], got %v", got)
+			}
+			if got := f.ChunkFileURI(); got != "chunk" {
+				t.Errorf("expected chunk file uri chunk, got %q", got)
+			}
+		})
+	}
+}
+
+func TestPopulateDeltaMappingArray(t *testing.T) {
+	coreIndex := &CoreIndexFile{Operations: CoreOperations{
+		Create:  []CreateOperation{{}},
+		Recover: []Operation{{DIDSuffix: "recover-did", RevealValue: "r"}},
+	}}
+	if err := coreIndex.populateCoreOperationArray(); err != nil {
+		t.Fatal(err)
+	}
+	provisionalIndex := &ProvisionalIndexFile{updateMappingArray: []string{"update-did"}}
+
+	tests := map[string]struct {
+		processor *OperationsProcessor
+		want      error
+	}{
+		"no provisional index": {processor: &OperationsProcessor{coreIndexFile: coreIndex}, want: ErrMissingPrerequisite},
+		"no core index":        {processor: &OperationsProcessor{provisionalIndexFile: provisionalIndex}, want: ErrMissingPrerequisite},
+		// A processor that has not started processing has no op maps yet.
+		"both index files": {processor: &OperationsProcessor{coreIndexFile: coreIndex, provisionalIndexFile: provisionalIndex}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.processor.populateDeltaMappingArray()
+			if test.want != nil {
+				if !errors.Is(err, test.want) {
+					t.Errorf("expected %v, got %v", test.want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			p := test.processor
+			if len(p.createOps) != 1 || len(p.createMappingArray) != 1 {
+				t.Errorf("expected one create mapped, got %v and %v", p.createOps, p.createMappingArray)
+			}
+			if !reflect.DeepEqual(p.recoveryMappingArray, []string{"recover-did"}) || !reflect.DeepEqual(p.updateMappingArray, []string{"update-did"}) {
+				t.Errorf("expected recover-did and update-did mapped, got %v and %v", p.recoveryMappingArray, p.updateMappingArray)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package sidetree
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/operations"
@@ -12,59 +11,88 @@ var (
 	ErrUpdateMappingMismatch = fmt.Errorf("update operation mapping array contains less entries than update entries")
 )
 
-func NewProvisionalProofFile(processor *OperationsProcessor, data []byte) (*ProvisionalProofFile, error) {
-	var p ProvisionalProofFile
-	if err := json.Unmarshal(data, &p); err != nil {
+// NewProvisionalProofFile decodes a provisional proof file. Process validates
+// it against the batch's provisional index file.
+func NewProvisionalProofFile(data []byte, opts ...FileOption) (*ProvisionalProofFile, error) {
+	p := ProvisionalProofFile{opts: newFileOptions(opts)}
+	if err := decodeJSON(p.opts.decodeMode, data, &p); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal provisional proof file: %w", err)
 	}
-	p.processor = processor
 	return &p, nil
 }
 
 type ProvisionalProofFile struct {
 	Operations ProvProofOperations `json:"operations"`
 
-	verifiedOps map[string]string
-	processor   *OperationsProcessor
+	opts fileOptions
+
+	// Set by Process.
+	updateOps map[string]operations.UpdateInterface
+	signedDataResults
 }
 
-func (p *ProvisionalProofFile) Process() error {
-	// p.processor.log.Infof("Processing provisional proof file %s", p.processor.ProvisionalProofFileURI)
+// Process pairs each proof with the update entry at the same index of
+// provisionalIndex, which must have been processed, building the update
+// operations, and verifies their signed data if enabled.
+func (p *ProvisionalProofFile) Process(provisionalIndex *ProvisionalIndexFile) error {
+	// The reveal values are collected when the provisional index file is
+	// processed.
+	if provisionalIndex == nil || provisionalIndex.revealValues == nil {
+		return missingPrerequisite("provisional proof file", "a processed provisional index file")
+	}
 	//TODO Check Max Provisional Proof File Size
 
-	if len(p.Operations.Update) == len(p.processor.provisionalIndexFile.Operations.Update) {
+	if len(p.Operations.Update) != len(provisionalIndex.Operations.Update) {
+		return ErrProofIndexMismatch
+	}
 
-		if len(p.processor.updateMappingArray) < len(p.Operations.Update) {
-			return ErrUpdateMappingMismatch
-		}
+	if len(provisionalIndex.updateMappingArray) < len(p.Operations.Update) {
+		return ErrUpdateMappingMismatch
+	}
 
-		p.verifiedOps = map[string]string{}
+	p.updateOps = map[string]operations.UpdateInterface{}
 
-		for i, op := range p.Operations.Update {
-			p.setUpdateOp(i, op)
+	for i, op := range p.Operations.Update {
+		if err := p.setUpdateOp(provisionalIndex, i, op); err != nil {
+			return err
 		}
-
-	} else {
-		return ErrProofIndexMismatch
 	}
 
 	return nil
 }
 
-func (p *ProvisionalProofFile) setUpdateOp(index int, update SignedUpdateDataOp) {
-	id := p.processor.updateMappingArray[index]
+func (p *ProvisionalProofFile) setUpdateOp(provisionalIndex *ProvisionalIndexFile, index int, update SignedUpdateDataOp) error {
+	id := provisionalIndex.updateMappingArray[index]
 
-	reveal, ok := p.processor.provisionalIndexFile.revealValues[id]
+	// The mapping array and the reveal values come from the same update
+	// entries of a processed provisional index, so only an index whose state
+	// was set up some other way can miss one.
+	reveal, ok := provisionalIndex.revealValues[id]
 	if !ok {
-		//fmt.Errorf("core index: %s - failed to find reveal value for id %s", p.processor.CoreIndexFileURI, id)
-		return
+		return fmt.Errorf("%w: no reveal value for update %s", ErrMissingPrerequisite, id)
 	}
 
-	p.processor.updateOps[id] = operations.UpdateOperation(
+	if p.updateOps == nil {
+		p.updateOps = map[string]operations.UpdateInterface{}
+	}
+	p.updateOps[id] = operations.UpdateOperation(
 		id,
 		reveal,
 		update.SignedData,
 	)
+
+	if p.opts.verifySignatures {
+		deltaHash, err := verifyUpdateSignedData(reveal, update.SignedData)
+		p.record(id, deltaHash, err)
+	}
+
+	return nil
+}
+
+// UpdateOps returns the update operations, keyed by DID suffix. The chunk
+// file supplies their deltas. It is nil until Process succeeds.
+func (p *ProvisionalProofFile) UpdateOps() map[string]operations.UpdateInterface {
+	return p.updateOps
 }
 
 type ProvProofOperations struct {
@@ -2,20 +2,19 @@ package sidetree
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
-
-	"github.com/13x-tech/ion-sdk-go/pkg/operations"
 )
 
 func TestNewProvProof(t *testing.T) {
 	t.Run("bad data", func(t *testing.T) {
-		_, err := NewProvisionalProofFile(&OperationsProcessor{}, []byte("bad data"))
+		_, err := NewProvisionalProofFile([]byte("bad data"))
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
 	})
 	t.Run("empty object", func(t *testing.T) {
-		_, err := NewProvisionalProofFile(&OperationsProcessor{}, []byte("{}"))
+		_, err := NewProvisionalProofFile([]byte("{}"))
 		if err != nil {
 			t.Errorf("expected nil, got %v", err)
 		}
@@ -52,6 +51,17 @@ func TestProvProofProcess(t *testing.T) {
 			},
 			want: ErrProofIndexMismatch,
 		},
+		"mapped update has no reveal value": {
+			updateMapping: []string{"id1", "id2"},
+			revealValues:  map[string]string{"id1": "value1", "id3": "value3"},
+			provUpdates:   []Operation{{RevealValue: "value1"}, {RevealValue: "value3"}},
+			proofFile: ProvisionalProofFile{
+				Operations: ProvProofOperations{
+					Update: []SignedUpdateDataOp{{SignedData: "signedData1"}, {SignedData: "signedData2"}},
+				},
+			},
+			want: ErrMissingPrerequisite,
+		},
 		"update mapping array count mismatch": {
 			updateMapping: []string{"id1"},
 			revealValues:  map[string]string{"id1": "value1", "id2": "value2"},
@@ -67,15 +77,12 @@ func TestProvProofProcess(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			p := &OperationsProcessor{
-				updateOps:          map[string]operations.UpdateInterface{},
-				updateMappingArray: test.updateMapping,
-				provisionalIndexFile: &ProvisionalIndexFile{
-					Operations: ProvOPS{
-						Update: test.provUpdates,
-					},
-					revealValues: test.revealValues,
+			index := &ProvisionalIndexFile{
+				Operations: ProvOPS{
+					Update: test.provUpdates,
 				},
+				revealValues:       test.revealValues,
+				updateMappingArray: test.updateMapping,
 			}
 
 			ppfJSON, err := json.Marshal(test.proofFile)
@@ -83,12 +90,12 @@ func TestProvProofProcess(t *testing.T) {
 				t.Errorf("error marshalling proof file: %v", err)
 			}
 
-			ppf, err := NewProvisionalProofFile(p, ppfJSON)
+			ppf, err := NewProvisionalProofFile(ppfJSON)
 			if err != nil {
 				t.Errorf("error creating provisional proof file: %v", err)
 			}
 
-			if err := ppf.Process(); err != test.want {
+			if err := ppf.Process(index); !errors.Is(err, test.want) {
 				t.Errorf("expected %v, got %v", test.want, err)
 			}
 
@@ -102,6 +109,7 @@ func TestProvProofSetUpdateOp(t *testing.T) {
 		revealValues  map[string]string
 		updateOps     map[int]SignedUpdateDataOp
 		expected      int
+		wantErr       error
 	}{
 		"reveal value missing": {
 			updateMapping: []string{"id1", "id2"},
@@ -111,6 +119,7 @@ func TestProvProofSetUpdateOp(t *testing.T) {
 				1: {SignedData: "signedData2"},
 			},
 			expected: 1,
+			wantErr:  ErrMissingPrerequisite,
 		},
 		"nothing missing": {
 			updateMapping: []string{"id1", "id2"},
@@ -125,25 +134,67 @@ func TestProvProofSetUpdateOp(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			p := &OperationsProcessor{
-				updateOps:          map[string]operations.UpdateInterface{},
+			index := &ProvisionalIndexFile{
+				revealValues:       test.revealValues,
 				updateMappingArray: test.updateMapping,
-				provisionalIndexFile: &ProvisionalIndexFile{
-					revealValues: test.revealValues,
-				},
-			}
-			ppf := ProvisionalProofFile{
-				processor: p,
 			}
+			ppf := ProvisionalProofFile{}
 
+			var err error
 			for id, op := range test.updateOps {
-				ppf.setUpdateOp(id, op)
+				if opErr := ppf.setUpdateOp(index, id, op); opErr != nil {
+					err = opErr
+				}
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, err)
 			}
 
-			if len(p.updateOps) != test.expected {
-				t.Errorf("expected %d update ops, got %d", test.expected, len(p.updateOps))
+			if len(ppf.UpdateOps()) != test.expected {
+				t.Errorf("expected %d update ops, got %d", test.expected, len(ppf.UpdateOps()))
 			}
 
 		})
 	}
 }
+
+func TestProvProofProcessMissingPrerequisites(t *testing.T) {
+	data := []byte(`{"operations":{"update":[{"signedData":"x"}]}}`)
+	index := &ProvisionalIndexFile{Operations: ProvOPS{Update: []Operation{{DIDSuffix: "update-did", RevealValue: "r"}}}}
+	processedIndex := &ProvisionalIndexFile{
+		Operations:         index.Operations,
+		revealValues:       map[string]string{"update-did": "r"},
+		updateMappingArray: []string{"update-did"},
+	}
+
+	tests := map[string]struct {
+		provisionalIndex *ProvisionalIndexFile
+		want             error
+	}{
+		"no provisional index":          {want: ErrMissingPrerequisite},
+		"unprocessed provisional index": {provisionalIndex: index, want: ErrMissingPrerequisite},
+		"processed provisional index":   {provisionalIndex: processedIndex},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := NewProvisionalProofFile(data)
+			if err != nil {
+				t.Fatalf("expected no error decoding, got %v", err)
+			}
+			err = f.Process(test.provisionalIndex)
+			if test.want != nil {
+				if !errors.Is(err, test.want) {
+					t.Errorf("expected %v, got %v", test.want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if _, ok := f.UpdateOps()["update-did"]; !ok {
+				t.Errorf("expected an update for update-did, got %v", f.UpdateOps())
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package sidetree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// OperationQueue holds the operation requests a node has accepted and not yet
+// anchored. The HTTP handler enqueues requests; a BatchWriter writes them and
+// removes them once they are anchored, or once they can never be.
+type OperationQueue interface {
+	// Enqueue adds a request and returns its id. Once it returns, the request
+	// must survive a restart.
+	Enqueue(request []byte) (uint64, error)
+	// Pending returns the queued requests in the order they were enqueued.
+	Pending() ([]QueuedOperation, error)
+	// Remove drops the requests with ids. Unknown ids are ignored.
+	Remove(ids ...uint64) error
+}
+
+// QueuedOperation is an operation request in an OperationQueue.
+type QueuedOperation struct {
+	ID      uint64          `json:"id"`
+	Request json.RawMessage `json:"request"`
+}
+
+// FileQueue is an OperationQueue stored in a file, one JSON line per queued
+// request. Enqueue appends and syncs a line; Remove rewrites the file through a
+// temporary file and a rename, so a crash leaves either the old queue or the
+// new one. It is safe for concurrent use within one process.
+type FileQueue struct {
+	path string
+
+	mu      sync.Mutex
+	pending []QueuedOperation
+	nextID  uint64
+}
+
+var _ OperationQueue = (*FileQueue)(nil)
+
+// OpenFileQueue opens the queue stored at path, creating it on the first
+// Enqueue if it does not exist. A last line cut short by a crash during
+// Enqueue is dropped; that request was never acknowledged.
+func OpenFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{path: path, nextID: 1}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Every complete line ends in a newline, so the last element is either
+	// empty or a line cut short.
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines[:len(lines)-1] {
+		var op QueuedOperation
+		if err := json.Unmarshal(line, &op); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, i+1, err)
+		}
+		q.pending = append(q.pending, op)
+		q.nextID = max(q.nextID, op.ID+1)
+	}
+	if len(lines[len(lines)-1]) > 0 {
+		// Drop the cut line before the next Enqueue appends to it.
+		if err := writeFileAtomic(path, data[:len(data)-len(lines[len(lines)-1])]); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+func (q *FileQueue) Enqueue(request []byte) (uint64, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, request); err != nil {
+		return 0, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	op := QueuedOperation{ID: q.nextID, Request: compact.Bytes()}
+	line, err := json.Marshal(op)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+
+	q.pending = append(q.pending, op)
+	q.nextID++
+	return op.ID, nil
+}
+
+func (q *FileQueue) Pending() ([]QueuedOperation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueuedOperation(nil), q.pending...), nil
+}
+
+func (q *FileQueue) Remove(ids ...uint64) error {
+	remove := map[uint64]struct{}{}
+	for _, id := range ids {
+		remove[id] = struct{}{}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var kept []QueuedOperation
+	var buf bytes.Buffer
+	for _, op := range q.pending {
+		if _, ok := remove[op.ID]; ok {
+			continue
+		}
+		line, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		kept = append(kept, op)
+	}
+	if len(kept) == len(q.pending) {
+		return nil
+	}
+
+	if err := writeFileAtomic(q.path, buf.Bytes()); err != nil {
+		return err
+	}
+	q.pending = kept
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
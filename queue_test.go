@@ -0,0 +1,65 @@
+package sidetree
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue")
+	q, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("expected no error opening a new queue, got %v", err)
+	}
+
+	for _, request := range []string{`{"type": "deactivate"}`, `{"type":"update"}`, `{"type":"recover"}`} {
+		if _, err := q.Enqueue([]byte(request)); err != nil {
+			t.Fatalf("expected no error enqueuing, got %v", err)
+		}
+	}
+	if err := q.Remove(2); err != nil {
+		t.Fatalf("expected no error removing, got %v", err)
+	}
+	want := []QueuedOperation{
+		{ID: 1, Request: []byte(`{"type":"deactivate"}`)},
+		{ID: 3, Request: []byte(`{"type":"recover"}`)},
+	}
+	if got, _ := q.Pending(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// A crash while appending leaves a cut line, which reopening drops.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"id":4,"requ`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("expected no error reopening, got %v", err)
+	}
+	if got, _ := reopened.Pending(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the queue to survive reopening as %v, got %v", want, got)
+	}
+	id, err := reopened.Enqueue([]byte(`{"type":"create"}`))
+	if err != nil {
+		t.Fatalf("expected no error enqueuing, got %v", err)
+	}
+	if id != 4 {
+		t.Errorf("expected id 4 after the last queued id, got %d", id)
+	}
+
+	again, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("expected no error reopening, got %v", err)
+	}
+	if got, _ := again.Pending(); len(got) != 3 || got[2].ID != 4 {
+		t.Errorf("expected the enqueued request after the others, got %v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package sidetree
+
+import (
+	"fmt"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// Operation requests are checked before they are queued for anchoring, the
+// way a node's POST /operations does, so that a queued operation is one the
+// reader in this package applies: its references are well formed, its delta
+// is within MaxDeltaSizeInBytes and hashes to what the operation commits to,
+// its commitments are well formed and its signed data verifies. A rejection
+// wraps the sentinel the reader would report for the same fault.
+//
+// The checks are against the request alone. Whether a reveal value matches the
+// DID's current commitment depends on the DID's state and is left to apply
+// time.
+
+// ParseOperationRequest strictly decodes an operation request, a create,
+// recover, update or deactivate JSON object as a node's POST /operations
+// accepts it, and validates it with ValidateOperation. It returns an
+// *operations.Create, *operations.Recover, *operations.Update or
+// *operations.Deactivate, ready for NewBatch or PackBatches.
+func ParseOperationRequest(data []byte) (interface{}, error) {
+	var req operations.Op
+	if err := decodeJSON(DecodeLenient, data, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode operation request: %w", err)
+	}
+
+	var op interface{}
+	switch OperationType(req.Type) {
+	case OperationCreate:
+		op = &operations.Create{}
+	case OperationRecover:
+		op = &operations.Recover{}
+	case OperationUpdate:
+		op = &operations.Update{}
+	case OperationDeactivate:
+		op = &operations.Deactivate{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedOperation, req.Type)
+	}
+	if err := decodeJSON(DecodeStrict, data, op); err != nil {
+		return nil, fmt.Errorf("failed to decode %s request: %w", req.Type, err)
+	}
+
+	if err := ValidateOperation(op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// ValidateOperation checks op, one of the operation types NewBatch accepts, on
+// its own.
+func ValidateOperation(op interface{}) error {
+	switch op := op.(type) {
+	case *operations.Create:
+		if _, err := op.SuffixData.URI(); err != nil {
+			return fmt.Errorf("%w: %w: failed to compute DID suffix: %w", ErrMalformed, ErrInvalidDIDSuffix, err)
+		}
+		if err := checkCommitment("create recoveryCommitment", op.SuffixData.RecoveryCommitment); err != nil {
+			return err
+		}
+		return checkRequestDelta("create", op.Delta, op.SuffixData.DeltaHash)
+
+	case *operations.Recover:
+		if err := checkOperationReference(CheckReferenceMultihashes, "recover", Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue}); err != nil {
+			return err
+		}
+		payload, err := verifyRecoverPayload(op.RevealValue, op.SignedData)
+		if err != nil {
+			return fmt.Errorf("recover %s: %w", op.DIDSuffix, err)
+		}
+		if err := checkCommitment("recover recoveryCommitment", payload.RecoveryCommitment); err != nil {
+			return err
+		}
+		return checkRequestDelta("recover", op.Delta, payload.DeltaHash)
+
+	case *operations.Update:
+		if err := checkOperationReference(CheckReferenceMultihashes, "update", Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue}); err != nil {
+			return err
+		}
+		signedHash, err := verifyUpdateSignedData(op.RevealValue, op.SignedData)
+		if err != nil {
+			return fmt.Errorf("update %s: %w", op.DIDSuffix, err)
+		}
+		return checkRequestDelta("update", op.Delta, signedHash)
+
+	case *operations.Deactivate:
+		if err := checkOperationReference(CheckReferenceMultihashes, "deactivate", Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue}); err != nil {
+			return err
+		}
+		if err := verifyDeactivateSignedData(op.DIDSuffix, op.RevealValue, op.SignedData); err != nil {
+			return fmt.Errorf("deactivate %s: %w", op.DIDSuffix, err)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedOperation, op)
+	}
+}
+
+// checkRequestDelta checks the delta of a name operation against the size cap
+// and the deltaHash the operation commits to, and its update commitment.
+func checkRequestDelta(name string, delta did.Delta, wantHash string) error {
+	if err := checkOperationDelta(&delta); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	got, err := delta.Hash()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if got != wantHash {
+		return fmt.Errorf("%w: %s committed to %q, delta hashes to %q", ErrDeltaHashMismatch, name, wantHash, got)
+	}
+	return checkCommitment(name+" updateCommitment", delta.UpdateCommitment)
+}
+
+// checkCommitment rejects a commitment that is not a base64url-encoded
+// SHA-256 multihash, which can never be revealed, as ErrMalformed. name
+// identifies the commitment for the error message.
+func checkCommitment(name, commitment string) error {
+	if err := checkEncodedMultihash(commitment); err != nil {
+		return fmt.Errorf("%w: %s %q is not a base64url-encoded SHA-256 multihash: %w", ErrMalformed, name, commitment, err)
+	}
+	return nil
+}
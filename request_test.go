@@ -0,0 +1,179 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestParseOperationRequest(t *testing.T) {
+	signer := newTestSigner(t, "request")
+	other := newTestSigner(t, "other")
+	commitment := encodedSHA256Multihash([]byte("commitment"))
+	suffix := encodedSHA256Multihash([]byte("did"))
+	delta := did.Delta{Patches: []map[string]interface{}{}, UpdateCommitment: commitment}
+
+	marshal := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		return string(b)
+	}
+	create := func(suffixData did.SuffixData, delta did.Delta) string {
+		op := operations.CreateOperation(suffixData)
+		op.SetDelta(delta)
+		return marshal(op)
+	}
+	update := func(suffix, reveal, signedData string, delta did.Delta) string {
+		op := operations.UpdateOperation(suffix, reveal, signedData)
+		op.SetDelta(delta)
+		return marshal(op)
+	}
+	recover := func(signedData string, delta did.Delta) string {
+		op := operations.RecoverOperation(suffix, signer.reveal, signedData)
+		op.SetDelta(delta)
+		return marshal(op)
+	}
+	signRecover := func(recoveryCommitment string, delta did.Delta) string {
+		return signer.sign(t, map[string]interface{}{
+			"recoveryCommitment": recoveryCommitment,
+			"recoveryKey":        signer.jwk,
+			"deltaHash":          testDeltaHash(t, delta),
+		})
+	}
+	badCommitment := did.Delta{Patches: []map[string]interface{}{}, UpdateCommitment: "not-a-commitment"}
+	suffixData := did.SuffixData{DeltaHash: testDeltaHash(t, delta), RecoveryCommitment: commitment}
+
+	tests := map[string]struct {
+		request string
+		want    OperationType
+		wantErr error
+	}{
+		"create": {
+			request: create(suffixData, delta),
+			want:    OperationCreate,
+		},
+		"recover": {
+			request: recover(signRecover(commitment, delta), delta),
+			want:    OperationRecover,
+		},
+		"update": {
+			request: update(suffix, signer.reveal, signer.signUpdate(t, delta), delta),
+			want:    OperationUpdate,
+		},
+		"deactivate": {
+			request: marshal(operations.DeactivateOperation(suffix, signer.reveal, signer.signDeactivate(t, suffix))),
+			want:    OperationDeactivate,
+		},
+		"unknown type": {
+			request: `{"type":"rotate"}`,
+			wantErr: ErrUnsupportedOperation,
+		},
+		"unknown property": {
+			request: `{"type":"deactivate","didSuffix":"a","revealValue":"r","signedData":"x","delta":{}}`,
+			wantErr: ErrUnknownProperty,
+		},
+		"duplicate property": {
+			request: `{"type":"deactivate","didSuffix":"a","didSuffix":"b","revealValue":"r","signedData":"x"}`,
+			wantErr: ErrDuplicateProperty,
+		},
+		"create with invalid recovery commitment": {
+			request: create(did.SuffixData{DeltaHash: suffixData.DeltaHash, RecoveryCommitment: "not-a-commitment"}, delta),
+			wantErr: ErrMalformed,
+		},
+		"create with invalid update commitment": {
+			request: create(did.SuffixData{DeltaHash: testDeltaHash(t, badCommitment), RecoveryCommitment: commitment}, badCommitment),
+			wantErr: ErrMalformed,
+		},
+		"create with another delta": {
+			request: create(did.SuffixData{DeltaHash: testDeltaHash(t, badCommitment), RecoveryCommitment: commitment}, delta),
+			wantErr: ErrDeltaHashMismatch,
+		},
+		"create with oversized delta": {
+			request: create(suffixData, did.Delta{UpdateCommitment: strings.Repeat("c", MaxDeltaSizeInBytes)}),
+			wantErr: ErrDeltaTooLarge,
+		},
+		"recover with invalid recovery commitment": {
+			request: recover(signRecover("not-a-commitment", delta), delta),
+			wantErr: ErrMalformed,
+		},
+		"update with invalid DID suffix": {
+			request: update("not-a-suffix", signer.reveal, signer.signUpdate(t, delta), delta),
+			wantErr: ErrInvalidDIDSuffix,
+		},
+		"update with invalid reveal value": {
+			request: update(suffix, "not-a-reveal", signer.signUpdate(t, delta), delta),
+			wantErr: ErrInvalidRevealValue,
+		},
+		"update with invalid signed data": {
+			request: update(suffix, signer.reveal, "not-a-jws", delta),
+			wantErr: ErrInvalidSignedData,
+		},
+		"update signed by another key": {
+			request: update(suffix, signer.reveal, other.signUpdate(t, delta), delta),
+			wantErr: ErrRevealValueMismatch,
+		},
+		"update with another delta": {
+			request: update(suffix, signer.reveal, signer.signUpdate(t, badCommitment), delta),
+			wantErr: ErrDeltaHashMismatch,
+		},
+		"update with invalid update commitment": {
+			request: update(suffix, signer.reveal, signer.signUpdate(t, badCommitment), badCommitment),
+			wantErr: ErrMalformed,
+		},
+		"deactivate signed for another DID": {
+			request: marshal(operations.DeactivateOperation(suffix, signer.reveal, signer.signDeactivate(t, commitment))),
+			wantErr: ErrDIDSuffixMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			op, err := ParseOperationRequest([]byte(test.request))
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("expected %v, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// An accepted request must survive the reader.
+			opType, opSuffix, _, err := describeOperation(op)
+			if err != nil {
+				t.Fatalf("expected a supported operation, got %v", err)
+			}
+			if opType != test.want {
+				t.Errorf("expected a %s operation, got %s", test.want, opType)
+			}
+			b, err := NewBatch([]interface{}{op})
+			if err != nil {
+				t.Fatalf("expected no error building batch, got %v", err)
+			}
+			cas := NewTestCAS()
+			anchor, err := b.Write(cas)
+			if err != nil {
+				t.Fatalf("expected no error writing batch, got %v", err)
+			}
+			p, err := Processor(operations.Anchor{Anchor: anchor}, WithCAS(cas), WithPrefix("test"),
+				WithSignatureVerification(true), WithMultihashValidation(CheckReferenceMultihashes))
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+			got := p.Process()
+			if got.Error != nil {
+				t.Fatalf("expected the batch to process, got %v", got.Error)
+			}
+			if result, ok := got.Results[opSuffix]; !ok || !result.Valid() {
+				t.Errorf("expected a valid result for %s, got %+v", opSuffix, got.Results)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package sidetree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// Resolution errors. ErrDIDDeactivated is returned with the DID's last
+// resolution result, which a resolver reports as deactivated rather than
+// missing.
+var (
+	ErrDIDNotFound        = fmt.Errorf("DID not found")
+	ErrDIDDeactivated     = fmt.Errorf("DID deactivated")
+	ErrCommitmentMismatch = fmt.Errorf("reveal value does not match the DID's commitment")
+
+	// ErrTransactionNumberApplied: State.Apply was given an anchor at a
+	// transaction number an anchor was already applied at, the same anchor
+	// again or another one.
+	ErrTransactionNumberApplied = fmt.Errorf("transaction number already applied")
+)
+
+// Resolver resolves a DID, by its suffix, to its DID resolution result.
+type Resolver interface {
+	Resolve(didSuffix string) (*did.Document, error)
+}
+
+// State is the resolution state of the DIDs in the anchors applied to it: the
+// operations of each DID in transaction-number order. Resolve replays a DID's
+// operations the way the Sidetree spec applies them, so an operation that does
+// not apply (a reveal value that does not match the current commitment, signed
+// data that does not verify, an operation before the create or after a
+// deactivate) is skipped and the rest still apply. It is safe for concurrent
+// use.
+//
+// It is configured with SideTreeOptions: WithDIDChangeHooks registers hooks
+// called for every DID an applied anchor changes.
+type State struct {
+	prefix string
+	hooks  []DIDChangeHooks
+
+	mu        sync.RWMutex
+	histories map[string][]stateOperation
+	// applied maps the transaction number of every anchor applied to its
+	// anchor string.
+	applied map[TransactionNumber]string
+}
+
+var _ Resolver = (*State)(nil)
+
+// stateOperation is an operation in a DID's history, with the position of
+// the anchor that carried it.
+type stateOperation struct {
+	transactionNumber TransactionNumber
+	opType            OperationType
+	op                interface{}
+}
+
+// NewState returns an empty State for DIDs of the method prefix, the method
+// name the processor is configured WithPrefix.
+func NewState(prefix string, options ...SideTreeOption) *State {
+	s := &State{prefix: prefix, histories: map[string][]stateOperation{}, applied: map[TransactionNumber]string{}}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Apply adds the valid operations of a processed anchor to the state, at its
+// transaction number: after the operations of every anchor applied before it
+// at the same or an earlier number. A batch that failed as a whole adds
+// nothing.
+//
+// An anchor whose content was unavailable (ErrContentUnavailable) is applied
+// once it resolves, late, at its own transaction number. Its operations splice
+// into the histories before those of later anchors, which may then no longer
+// apply: an update that revealed the commitment a late update already
+// revealed is skipped. Apply replays every DID the anchor touches and calls
+// OnDIDChanged, after the state is updated, for each whose resolution result
+// changed.
+//
+// Each transaction number is applied once. Apply returns an error, and adds
+// nothing, for an anchor without a transaction number (its
+// TransactionNumberError, wrapping ErrInvalidTransactionNumber) and for one at
+// a transaction number already applied (ErrTransactionNumberApplied).
+func (s *State) Apply(ops ProcessedOperations) error {
+	if ops.Error != nil {
+		return nil
+	}
+	if ops.TransactionNumberError != nil {
+		return fmt.Errorf("failed to apply %s: %w", ops.AnchorString, ops.TransactionNumberError)
+	}
+
+	changed, err := s.apply(ops)
+	if err != nil {
+		return err
+	}
+	anchor := operations.Anchor{
+		Anchor:   operations.AnchorString(ops.AnchorString),
+		Sequence: operations.SequenceSignature(ops.AnchorSequence),
+	}
+	for _, suffix := range changed {
+		for _, h := range s.hooks {
+			h.OnDIDChanged(anchor, suffix)
+		}
+	}
+	return nil
+}
+
+// apply adds ops to the histories and returns the suffixes of the DIDs whose
+// resolution result changed, in order.
+func (s *State) apply(ops ProcessedOperations) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if anchor, ok := s.applied[ops.TransactionNumber]; ok {
+		return nil, fmt.Errorf("%w: %s at %s, already applied with %s", ErrTransactionNumberApplied, ops.AnchorString, ops.TransactionNumber, anchor)
+	}
+	s.applied[ops.TransactionNumber] = ops.AnchorString
+
+	before := map[string]*replay{}
+	add := func(opType OperationType, suffix string, op interface{}) {
+		history := s.histories[suffix]
+		if _, ok := before[suffix]; !ok {
+			before[suffix] = s.replay(suffix, history)
+		}
+		i := sort.Search(len(history), func(i int) bool {
+			return history[i].transactionNumber > ops.TransactionNumber
+		})
+		// Insert into a copy: Resolve may be replaying the old history.
+		s.histories[suffix] = slices.Insert(slices.Clip(history), i, stateOperation{transactionNumber: ops.TransactionNumber, opType: opType, op: op})
+	}
+	for _, suffix := range sortedKeys(ops.CreateOps) {
+		add(OperationCreate, suffix, ops.CreateOps[suffix])
+	}
+	for _, suffix := range sortedKeys(ops.RecoverOps) {
+		add(OperationRecover, suffix, ops.RecoverOps[suffix])
+	}
+	for _, suffix := range sortedKeys(ops.UpdateOps) {
+		add(OperationUpdate, suffix, ops.UpdateOps[suffix])
+	}
+	for _, suffix := range sortedKeys(ops.DeactivateOps) {
+		add(OperationDeactivate, suffix, ops.DeactivateOps[suffix])
+	}
+
+	var changed []string
+	for _, suffix := range sortedKeys(before) {
+		if !before[suffix].equal(s.replay(suffix, s.histories[suffix])) {
+			changed = append(changed, suffix)
+		}
+	}
+	return changed, nil
+}
+
+// Resolve returns the current resolution result of the DID with suffix
+// didSuffix. A DID with no create that applies wraps ErrDIDNotFound; a
+// deactivated DID returns its result, with an empty document, and
+// ErrDIDDeactivated.
+func (s *State) Resolve(didSuffix string) (*did.Document, error) {
+	s.mu.RLock()
+	history := s.histories[didSuffix]
+	s.mu.RUnlock()
+
+	r := s.replay(didSuffix, history)
+	if r.doc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDIDNotFound, didSuffix)
+	}
+	if r.deactivated {
+		return r.doc, fmt.Errorf("%w: %s", ErrDIDDeactivated, didSuffix)
+	}
+	return r.doc, nil
+}
+
+// replay replays history, the operations of the DID with suffix.
+func (s *State) replay(suffix string, history []stateOperation) *replay {
+	r := &replay{prefix: s.prefix, suffix: suffix, published: true}
+	for _, op := range history {
+		// An operation that does not apply is skipped; the next one is
+		// checked against the same state.
+		_ = r.apply(op)
+	}
+	return r
+}
+
+// replay is the state of one DID as its history is replayed.
+type replay struct {
+	prefix    string
+	suffix    string
+	published bool
+
+	// doc is nil until a create applies.
+	doc         *did.Document
+	deactivated bool
+}
+
+// equal reports whether r and other resolve to the same result. Documents
+// are compared as they are served, in JSON.
+func (r *replay) equal(other *replay) bool {
+	if r.deactivated != other.deactivated || (r.doc == nil) != (other.doc == nil) {
+		return false
+	}
+	if r.doc == nil {
+		return true
+	}
+	a, errA := json.Marshal(r.doc)
+	b, errB := json.Marshal(other.doc)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// apply applies one operation, or returns why it does not apply.
+func (r *replay) apply(op stateOperation) error {
+	if op.opType == OperationCreate {
+		if r.doc != nil {
+			return fmt.Errorf("%w: %s already created", ErrDuplicateOperation, r.suffix)
+		}
+		create, ok := op.op.(operations.CreateInterface)
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrUnsupportedOperation, op.op)
+		}
+		return r.applyCreate(create)
+	}
+
+	if r.doc == nil {
+		return fmt.Errorf("%w: %s", ErrDIDNotFound, r.suffix)
+	}
+	if r.deactivated {
+		return fmt.Errorf("%w: %s", ErrDIDDeactivated, r.suffix)
+	}
+
+	switch op := op.op.(type) {
+	case operations.RecoverInterface:
+		return r.applyRecover(op)
+	case operations.UpdateInterface:
+		return r.applyUpdate(op)
+	case operations.DeactivateInterface:
+		return r.applyDeactivate(op)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedOperation, op)
+	}
+}
+
+// applyCreate creates the DID. A delta that does not match the suffix data
+// leaves the document empty, with no update commitment.
+func (r *replay) applyCreate(op operations.CreateInterface) error {
+	suffixData, delta, err := op.Operation()
+	if err != nil {
+		return err
+	}
+	r.doc = did.New(r.suffix, suffixData.RecoveryCommitment, r.prefix, r.published)
+	if err := checkDeltaHash(delta, suffixData.DeltaHash); err != nil {
+		return err
+	}
+	r.applyDelta(delta)
+	return nil
+}
+
+// applyRecover replaces the document and both commitments. A delta that does
+// not match the signed data leaves the document empty, with no update
+// commitment.
+func (r *replay) applyRecover(op operations.RecoverInterface) error {
+	_, revealValue, delta, signedData, err := op.Operation()
+	if err != nil {
+		return err
+	}
+	if !did.CheckReveal(revealValue, r.doc.Metadata.Method.RecoveryCommitment) {
+		return fmt.Errorf("%w: recover %s", ErrCommitmentMismatch, r.suffix)
+	}
+	payload, err := verifyRecoverPayload(revealValue, signedData)
+	if err != nil {
+		return err
+	}
+
+	r.doc.Metadata.Method.RecoveryCommitment = payload.RecoveryCommitment
+	r.doc.Metadata.Method.UpdateCommitment = ""
+	r.doc.Document.ResetData()
+	if err := checkDeltaHash(delta, payload.DeltaHash); err != nil {
+		return err
+	}
+	r.applyDelta(delta)
+	return nil
+}
+
+func (r *replay) applyUpdate(op operations.UpdateInterface) error {
+	_, revealValue, signedData, delta, err := op.Operation()
+	if err != nil {
+		return err
+	}
+	if !did.CheckReveal(revealValue, r.doc.Metadata.Method.UpdateCommitment) {
+		return fmt.Errorf("%w: update %s", ErrCommitmentMismatch, r.suffix)
+	}
+	signedHash, err := verifyUpdateSignedData(revealValue, signedData)
+	if err != nil {
+		return err
+	}
+	if err := checkDeltaHash(delta, signedHash); err != nil {
+		return err
+	}
+	r.applyDelta(delta)
+	return nil
+}
+
+// applyDeactivate empties the document and clears both commitments; no later
+// operation applies.
+func (r *replay) applyDeactivate(op operations.DeactivateInterface) error {
+	_, revealValue, signedData, err := op.Operation()
+	if err != nil {
+		return err
+	}
+	if !did.CheckReveal(revealValue, r.doc.Metadata.Method.RecoveryCommitment) {
+		return fmt.Errorf("%w: deactivate %s", ErrCommitmentMismatch, r.suffix)
+	}
+	if err := verifyDeactivateSignedData(r.suffix, revealValue, signedData); err != nil {
+		return err
+	}
+
+	r.doc.Metadata.Method.RecoveryCommitment = ""
+	r.doc.Metadata.Method.UpdateCommitment = ""
+	r.doc.Document.ResetData()
+	r.deactivated = true
+	return nil
+}
+
+// applyDelta moves the update commitment to the delta's and applies its
+// patches. Patches apply all or nothing: if one fails the document is left as
+// it was, but the commitment still moves.
+func (r *replay) applyDelta(delta did.Delta) {
+	r.doc.Metadata.Method.UpdateCommitment = delta.UpdateCommitment
+
+	patched, err := cloneDocument(r.doc)
+	if err != nil {
+		return
+	}
+	if err := operations.PatchData(r.prefix, delta, patched); err != nil {
+		return
+	}
+	r.doc.Document = patched.Document
+}
+
+// checkDeltaHash reports whether delta hashes to want.
+func checkDeltaHash(delta did.Delta, want string) error {
+	got, err := delta.Hash()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDeltaHashMismatch, err)
+	}
+	if got != want {
+		return fmt.Errorf("%w: committed to %q, delta hashes to %q", ErrDeltaHashMismatch, want, got)
+	}
+	return nil
+}
+
+// cloneDocument returns a deep copy of doc for patches to apply to.
+func cloneDocument(doc *did.Document) (*did.Document, error) {
+	data, err := json.Marshal(doc.Document)
+	if err != nil {
+		return nil, err
+	}
+	clone := *doc
+	clone.Document = &did.DocumentData{}
+	if err := json.Unmarshal(data, clone.Document); err != nil {
+		return nil, err
+	}
+	// ID is not marshaled.
+	clone.Document.ID = doc.Document.ID
+	return &clone, nil
+}
@@ -0,0 +1,397 @@
+package sidetree
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// testCommitment returns the commitment that reveal reveals.
+func testCommitment(t *testing.T, reveal string) string {
+	t.Helper()
+	raw, err := base64.RawURLEncoding.DecodeString(reveal)
+	if err != nil {
+		t.Fatalf("failed to decode reveal value: %v", err)
+	}
+	decoded, err := mh.Decode(raw)
+	if err != nil {
+		t.Fatalf("failed to decode reveal value: %v", err)
+	}
+	return encodedSHA256Multihash(decoded.Digest)
+}
+
+// testDID is a DID whose operations are signed by real keys, for resolution
+// tests.
+type testDID struct {
+	suffix string
+	create *operations.Create
+
+	recoveryKey, updateKey, nextUpdateKey testSigner
+}
+
+func newTestDID(t *testing.T, seed string) testDID {
+	t.Helper()
+	d := testDID{
+		recoveryKey:   newTestSigner(t, seed+"-recovery"),
+		updateKey:     newTestSigner(t, seed+"-update"),
+		nextUpdateKey: newTestSigner(t, seed+"-next-update"),
+	}
+	delta := did.Delta{
+		Patches:          []map[string]interface{}{testAddService("created")},
+		UpdateCommitment: testCommitment(t, d.updateKey.reveal),
+	}
+	d.create = operations.CreateOperation(did.SuffixData{
+		DeltaHash:          testDeltaHash(t, delta),
+		RecoveryCommitment: testCommitment(t, d.recoveryKey.reveal),
+	})
+	d.create.SetDelta(delta)
+
+	var err error
+	if d.suffix, err = d.create.SuffixData.URI(); err != nil {
+		t.Fatalf("failed to compute DID suffix: %v", err)
+	}
+	return d
+}
+
+// update returns an update signed by key that adds service and commits to
+// next.
+func (d testDID) update(t *testing.T, key testSigner, service string, next testSigner) *operations.Update {
+	t.Helper()
+	delta := did.Delta{
+		Patches:          []map[string]interface{}{testAddService(service)},
+		UpdateCommitment: testCommitment(t, next.reveal),
+	}
+	op := operations.UpdateOperation(d.suffix, key.reveal, key.signUpdate(t, delta))
+	op.SetDelta(delta)
+	return op
+}
+
+// recover returns a recover signed by key that replaces the document with
+// service and commits to next.
+func (d testDID) recover(t *testing.T, key testSigner, service string, next testSigner) *operations.Recover {
+	t.Helper()
+	delta := did.Delta{
+		Patches: []map[string]interface{}{{
+			"action":   "replace",
+			"document": map[string]interface{}{"services": testAddService(service)["services"]},
+		}},
+		UpdateCommitment: testCommitment(t, next.reveal),
+	}
+	op := operations.RecoverOperation(d.suffix, key.reveal, key.signRecover(t, delta))
+	op.SetDelta(delta)
+	return op
+}
+
+func (d testDID) deactivate(t *testing.T, key testSigner) *operations.Deactivate {
+	t.Helper()
+	return operations.DeactivateOperation(d.suffix, key.reveal, key.signDeactivate(t, d.suffix))
+}
+
+func testAddService(id string) map[string]interface{} {
+	return map[string]interface{}{
+		"action": "add-services",
+		"services": []interface{}{
+			map[string]interface{}{"id": id, "type": "LinkedDomains", "serviceEndpoint": "https://example.com/" + id},
+		},
+	}
+}
+
+// applyAnchors applies anchors to state in order, failing the test on an
+// error.
+func applyAnchors(t *testing.T, state *State, anchors ...ProcessedOperations) {
+	t.Helper()
+	for _, ops := range anchors {
+		if err := state.Apply(ops); err != nil {
+			t.Fatalf("failed to apply %s: %v", ops.AnchorString, err)
+		}
+	}
+}
+
+// testAnchor returns the processed operations of an anchor carrying ops.
+func testAnchor(height uint32, ops ...interface{}) ProcessedOperations {
+	txnum := NewTransactionNumber(height, 0)
+	p := ProcessedOperations{
+		AnchorString:      fmt.Sprintf("%d.%s", len(ops), testCID(fmt.Appendf(nil, "block%d", height))),
+		AnchorSequence:    string(txnum.Sequence("block", "tx")),
+		TransactionNumber: txnum,
+		CreateOps:         map[string]operations.CreateInterface{},
+		UpdateOps:         map[string]operations.UpdateInterface{},
+		DeactivateOps:     map[string]operations.DeactivateInterface{},
+		RecoverOps:        map[string]operations.RecoverInterface{},
+	}
+	for _, op := range ops {
+		switch op := op.(type) {
+		case *operations.Create:
+			suffix, _ := op.SuffixData.URI()
+			p.CreateOps[suffix] = op
+		case *operations.Update:
+			p.UpdateOps[op.DIDSuffix] = op
+		case *operations.Recover:
+			p.RecoverOps[op.DIDSuffix] = op
+		case *operations.Deactivate:
+			p.DeactivateOps[op.DIDSuffix] = op
+		}
+	}
+	return p
+}
+
+func serviceIDs(doc *did.Document) []string {
+	var ids []string
+	for _, s := range doc.Document.Services {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestStateResolve(t *testing.T) {
+	d := newTestDID(t, "resolve")
+	stranger := newTestSigner(t, "stranger")
+
+	tests := map[string]struct {
+		anchors           []ProcessedOperations
+		wantErr           error
+		wantServices      []string
+		wantUpdateKey     testSigner
+		wantNoCommitments bool
+		// wantRecoveryCommitment defaults to the commitment of d.recoveryKey.
+		wantRecoveryCommitment string
+	}{
+		"never created": {
+			anchors: []ProcessedOperations{testAnchor(1, d.update(t, d.updateKey, "updated", d.nextUpdateKey))},
+			wantErr: ErrDIDNotFound,
+		},
+		"created": {
+			anchors:       []ProcessedOperations{testAnchor(1, d.create)},
+			wantServices:  []string{"#created"},
+			wantUpdateKey: d.updateKey,
+		},
+		"updated": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+			},
+			wantServices:  []string{"#created", "#updated"},
+			wantUpdateKey: d.nextUpdateKey,
+		},
+		"update with the wrong key is skipped": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.update(t, stranger, "stranger", stranger)),
+				testAnchor(3, d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+			},
+			wantServices:  []string{"#created", "#updated"},
+			wantUpdateKey: d.nextUpdateKey,
+		},
+		"reused update key is skipped": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+				testAnchor(3, d.update(t, d.updateKey, "replayed", d.updateKey)),
+			},
+			wantServices:  []string{"#created", "#updated"},
+			wantUpdateKey: d.nextUpdateKey,
+		},
+		"operations before the create are skipped": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.update(t, d.updateKey, "early", d.nextUpdateKey)),
+				testAnchor(2, d.create),
+			},
+			wantServices:  []string{"#created"},
+			wantUpdateKey: d.updateKey,
+		},
+		"applied out of order": {
+			anchors: []ProcessedOperations{
+				testAnchor(2, d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+				testAnchor(1, d.create),
+			},
+			wantServices:  []string{"#created", "#updated"},
+			wantUpdateKey: d.nextUpdateKey,
+		},
+		"failed batch adds nothing": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				func() ProcessedOperations {
+					p := testAnchor(2, d.update(t, d.updateKey, "updated", d.nextUpdateKey))
+					p.Error = ErrMalformed
+					return p
+				}(),
+			},
+			wantServices:  []string{"#created"},
+			wantUpdateKey: d.updateKey,
+		},
+		"recovered": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+				testAnchor(3, d.recover(t, d.recoveryKey, "recovered", stranger)),
+			},
+			wantServices:           []string{"#recovered"},
+			wantUpdateKey:          stranger,
+			wantRecoveryCommitment: "next-recovery-commitment",
+		},
+		"recover with the update key is skipped": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.recover(t, d.updateKey, "recovered", stranger)),
+			},
+			wantServices:  []string{"#created"},
+			wantUpdateKey: d.updateKey,
+		},
+		"deactivated": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.deactivate(t, d.recoveryKey)),
+				testAnchor(3, d.update(t, d.updateKey, "updated", d.nextUpdateKey)),
+			},
+			wantErr:           ErrDIDDeactivated,
+			wantNoCommitments: true,
+		},
+		"deactivate with the update key is skipped": {
+			anchors: []ProcessedOperations{
+				testAnchor(1, d.create),
+				testAnchor(2, d.deactivate(t, d.updateKey)),
+			},
+			wantServices:  []string{"#created"},
+			wantUpdateKey: d.updateKey,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := NewState("test")
+			applyAnchors(t, state, test.anchors...)
+
+			doc, err := state.Resolve(d.suffix)
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if errors.Is(err, ErrDIDNotFound) {
+				return
+			}
+
+			if doc.Metadata.CanonicalId != "did:test:"+d.suffix || !doc.Metadata.Method.Published {
+				t.Errorf("expected published did:test:%s, got %+v", d.suffix, doc.Metadata)
+			}
+			if got := serviceIDs(doc); !reflect.DeepEqual(got, test.wantServices) {
+				t.Errorf("expected services %v, got %v", test.wantServices, got)
+			}
+			method := doc.Metadata.Method
+			if test.wantNoCommitments {
+				if method.UpdateCommitment != "" || method.RecoveryCommitment != "" {
+					t.Errorf("expected no commitments, got %+v", method)
+				}
+				return
+			}
+			if want := testCommitment(t, test.wantUpdateKey.reveal); method.UpdateCommitment != want {
+				t.Errorf("expected update commitment %q, got %q", want, method.UpdateCommitment)
+			}
+			want := test.wantRecoveryCommitment
+			if want == "" {
+				want = testCommitment(t, d.recoveryKey.reveal)
+			}
+			if method.RecoveryCommitment != want {
+				t.Errorf("expected recovery commitment %q, got %q", want, method.RecoveryCommitment)
+			}
+		})
+	}
+}
+
+func TestStateApplyRejects(t *testing.T) {
+	d := newTestDID(t, "apply-rejects")
+	create := testAnchor(1, d.create)
+	noPosition := create
+	noPosition.TransactionNumber, noPosition.TransactionNumberError = TransactionNumberFromSequence("42")
+	sameNumber := testAnchor(1, d.update(t, d.updateKey, "updated", d.nextUpdateKey))
+	failed := testAnchor(2, d.deactivate(t, d.recoveryKey))
+	failed.Error = ErrContentUnavailable
+
+	tests := map[string]struct {
+		before       []ProcessedOperations
+		ops          ProcessedOperations
+		wantErr      error
+		wantResolved bool
+	}{
+		"no transaction number":      {ops: noPosition, wantErr: ErrInvalidTransactionNumber},
+		"same anchor again":          {before: []ProcessedOperations{create}, ops: create, wantErr: ErrTransactionNumberApplied, wantResolved: true},
+		"other anchor at the number": {before: []ProcessedOperations{create}, ops: sameNumber, wantErr: ErrTransactionNumberApplied, wantResolved: true},
+		// A failed batch adds nothing and may be applied once it resolves.
+		"after a failed batch": {before: []ProcessedOperations{failed}, ops: create, wantResolved: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := NewState("test")
+			applyAnchors(t, state, test.before...)
+			if err := state.Apply(test.ops); !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+			if _, err := state.Resolve(d.suffix); (err == nil) != test.wantResolved {
+				t.Errorf("expected %s resolved %v, got %v", d.suffix, test.wantResolved, err)
+			}
+		})
+	}
+
+	// A rejected repeat leaves the anchor it repeats in place.
+	state := NewState("test")
+	applyAnchors(t, state, create)
+	if err := state.Apply(sameNumber); !errors.Is(err, ErrTransactionNumberApplied) {
+		t.Fatalf("expected %v, got %v", ErrTransactionNumberApplied, err)
+	}
+	doc, err := state.Resolve(d.suffix)
+	if err != nil {
+		t.Fatalf("expected %s to resolve, got %v", d.suffix, err)
+	}
+	if got := serviceIDs(doc); !reflect.DeepEqual(got, []string{"#created"}) {
+		t.Errorf("expected the rejected update not to apply, got services %v", got)
+	}
+}
+
+func TestStateLatePublishing(t *testing.T) {
+	a := newTestDID(t, "late-a")
+	b := newTestDID(t, "late-b")
+	stranger := newTestSigner(t, "stranger")
+	hooks := &recordingHooks{}
+	// Hooks registered WithHooks observe processing, not a State.
+	processing := &recordingHooks{}
+	state := NewState("test", WithHooks(processing), WithDIDChangeHooks(hooks))
+
+	creates := testAnchor(1, a.create, b.create)
+	onTime := testAnchor(3, a.update(t, a.updateKey, "on-time", a.nextUpdateKey))
+	late := testAnchor(2, a.update(t, a.updateKey, "late", stranger))
+	created := []string{"changed " + creates.AnchorString + " " + a.suffix, "changed " + creates.AnchorString + " " + b.suffix}
+	if b.suffix < a.suffix {
+		created[0], created[1] = created[1], created[0]
+	}
+	// The update at 4 does not apply, so it changes nothing. The late anchor
+	// at 2 reveals a's update key first, so the update at 3, revealing the
+	// same key, no longer applies.
+	applyAnchors(t, state, creates, onTime, testAnchor(4, b.update(t, stranger, "ignored", stranger)), late)
+
+	want := append(created, "changed "+onTime.AnchorString+" "+a.suffix, "changed "+late.AnchorString+" "+a.suffix)
+	if !reflect.DeepEqual(hooks.events, want) {
+		t.Errorf("expected notifications %v, got %v", want, hooks.events)
+	}
+	if len(processing.events) != 0 {
+		t.Errorf("expected no notifications to hooks registered WithHooks, got %v", processing.events)
+	}
+
+	doc, err := state.Resolve(a.suffix)
+	if err != nil {
+		t.Fatalf("expected %s to resolve, got %v", a.suffix, err)
+	}
+	if got, want := serviceIDs(doc), []string{"#created", "#late"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected services %v, got %v", want, got)
+	}
+	if want := testCommitment(t, stranger.reveal); doc.Metadata.Method.UpdateCommitment != want {
+		t.Errorf("expected the late update's commitment %q, got %q", want, doc.Metadata.Method.UpdateCommitment)
+	}
+}
@@ -0,0 +1,106 @@
+package sidetree
+
+import "fmt"
+
+// The Sidetree spec distinguishes failures that invalidate a whole batch (an
+// unavailable or malformed file, a count mismatch, a duplicate suffix) from
+// failures that invalidate a single operation (a bad signature, a delta that
+// does not match its commitment). The former are reported once, through
+// ProcessedOperations.Error. The latter are reported per operation through
+// ProcessedOperations.Results, so a resolver can skip the one operation and
+// still apply the rest of the batch.
+
+// OperationType is the kind of a Sidetree operation.
+type OperationType string
+
+const (
+	OperationCreate     OperationType = "create"
+	OperationRecover    OperationType = "recover"
+	OperationUpdate     OperationType = "update"
+	OperationDeactivate OperationType = "deactivate"
+)
+
+// OperationStatus is the outcome of processing a single operation.
+type OperationStatus int
+
+const (
+	// OperationValid operations are returned in the op maps of
+	// ProcessedOperations and may be applied by a resolver.
+	OperationValid OperationStatus = iota
+
+	// OperationInvalid operations were rejected on their own. They are not
+	// returned in the op maps; OperationResult.Reason says why.
+	OperationInvalid
+)
+
+func (s OperationStatus) String() string {
+	switch s {
+	case OperationValid:
+		return "valid"
+	case OperationInvalid:
+		return "invalid"
+	default:
+		return fmt.Sprintf("OperationStatus(%d)", int(s))
+	}
+}
+
+// OperationResult is the processing outcome of one anchored operation.
+type OperationResult struct {
+	Type   OperationType
+	Status OperationStatus
+
+	// Reason is the error that invalidated the operation, nil when Status is
+	// OperationValid.
+	Reason error
+}
+
+// Valid reports whether the operation may be applied.
+func (r OperationResult) Valid() bool {
+	return r.Status == OperationValid
+}
+
+// operationResults builds the result of every operation in the op maps,
+// marking those flagged by flagInvalidOp as invalid. It must run before
+// removeInvalidOps drops the invalid operations from the maps.
+func (d *OperationsProcessor) operationResults() map[string]OperationResult {
+	results := map[string]OperationResult{}
+
+	add := func(id string, opType OperationType) {
+		result := OperationResult{Type: opType, Status: OperationValid}
+		if reason, ok := d.invalidOps[id]; ok {
+			result.Status = OperationInvalid
+			result.Reason = reason
+		}
+		results[id] = result
+	}
+
+	for id := range d.createOps {
+		add(id, OperationCreate)
+	}
+	for id := range d.recoverOps {
+		add(id, OperationRecover)
+	}
+	for id := range d.updateOps {
+		add(id, OperationUpdate)
+	}
+	for id := range d.deactivateOps {
+		add(id, OperationDeactivate)
+	}
+
+	return results
+}
+
+func (d *OperationsProcessor) Results() map[string]OperationResult {
+	if len(d.filterDIDs) == 0 {
+		return d.results
+	}
+
+	results := map[string]OperationResult{}
+	for _, did := range d.filterDIDs {
+		if _, ok := d.results[did]; ok {
+			results[did] = d.results[did]
+		}
+	}
+
+	return results
+}
@@ -2,6 +2,7 @@ package sidetree
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/operations"
 )
@@ -38,6 +39,55 @@ func WithCAS(cas CAS) SideTreeOption {
 			t.cas = cas
 		case *OperationsProcessor:
 			t.cas = cas
+		case *BatchWriter:
+			t.cas = cas
+		}
+	}
+}
+
+// WithDecodeMode selects how Sidetree files are decoded. The default,
+// DecodeStrict, matches the reference implementation and is the only mode
+// suitable for consensus use.
+func WithDecodeMode(mode DecodeMode) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.decodeMode = mode
+		case *OperationsProcessor:
+			t.decodeMode = mode
+		}
+	}
+}
+
+// WithMultihashValidation validates the selected operation-reference fields as
+// base64url-encoded SHA-256 multihashes when the index files are parsed,
+// rejecting the batch on the first invalid one. CheckReferenceMultihashes
+// matches the reference implementation; the default checks nothing.
+func WithMultihashValidation(checks MultihashCheck) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.multihashChecks = checks
+		case *OperationsProcessor:
+			t.multihashChecks = checks
+		}
+	}
+}
+
+// WithSignatureVerification verifies the compact-JWS signed data of every
+// recover, update and deactivate operation while the proof files are
+// processed: the ES256K signature, the reveal value against the signing key
+// and the signed deltaHash against the anchored delta. An operation that fails
+// is reported as OperationInvalid in ProcessedOperations.Results instead of
+// failing the batch.
+// Off by default, leaving verification to ion-sdk-go's operation replay.
+func WithSignatureVerification(enabled bool) SideTreeOption {
+	return func(d interface{}) {
+		switch t := d.(type) {
+		case *SideTree:
+			t.verifySignatures = enabled
+		case *OperationsProcessor:
+			t.verifySignatures = enabled
 		}
 	}
 }
@@ -67,6 +117,17 @@ func WithFeeFunctions(feeFunctions ...interface{}) SideTreeOption {
 					t.valueLockFn = fn
 				}
 			}
+		case *BatchWriter:
+			for _, f := range feeFunctions {
+				switch fn := f.(type) {
+				case BaseFeeAlgorithm:
+					t.baseFeeFn = fn
+				case PerOperationFee:
+					t.perOpFeeFn = fn
+				case ValueLocking:
+					t.valueLockFn = fn
+				}
+			}
 		}
 	}
 }
@@ -90,6 +151,15 @@ type SideTree struct {
 	baseFeeFn   BaseFeeAlgorithm
 	perOpFeeFn  PerOperationFee
 	valueLockFn ValueLocking
+	decodeMode  DecodeMode
+
+	multihashChecks  MultihashCheck
+	verifySignatures bool
+
+	log      *slog.Logger
+	metrics  Metrics
+	hooks    []Hooks
+	resolver Resolver
 }
 
 // feeFunctions returns the configured fee / value-lock callbacks as a slice
@@ -128,6 +198,13 @@ func (s *SideTree) ProcessOperations(ops []operations.Anchor, ids []string) (map
 			WithPrefix(s.method),
 			WithCAS(s.cas),
 			WithDIDs(ids),
+			WithDecodeMode(s.decodeMode),
+			WithMultihashValidation(s.multihashChecks),
+			WithSignatureVerification(s.verifySignatures),
+			WithLogger(s.log),
+			WithMetrics(s.metrics),
+			WithHooks(s.hooks...),
+			WithResolver(s.resolver),
 		}
 		if len(feeFns) > 0 {
 			opts = append(opts, WithFeeFunctions(feeFns...))
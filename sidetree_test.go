@@ -92,10 +92,10 @@ func TestSideTreeProcessOperations(t *testing.T) {
 			),
 			ops: []operations.Anchor{{
 				Sequence: "1:abc:1:abc",
-				Anchor:   "2.abc",
+				Anchor:   "2." + coreIndexCID,
 			}, {
 				Sequence: "2:def:1:xyz",
-				Anchor:   "1.xyz",
+				Anchor:   "1." + otherCoreIndexCID,
 			}},
 			wantErr: nil,
 			want:    2,
@@ -132,7 +132,7 @@ func TestSideTreeProcessOperations(t *testing.T) {
 // fire during ProcessOperations. Before the fix the callbacks were silently
 // dropped and a >100-op / unlocked anchor would be accepted regardless.
 func TestProcessOperationsForwardsFeeFunctions(t *testing.T) {
-	op := operations.Anchor{Sequence: "1:abc:1:abc", Anchor: "1.abc"}
+	op := operations.Anchor{Sequence: "1:abc:1:abc", Anchor: "1." + coreIndexCID}
 
 	tests := map[string]struct {
 		valueLock ValueLocking
@@ -157,7 +157,7 @@ func TestProcessOperationsForwardsFeeFunctions(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			cas := NewTestCAS()
-			cas.insertObject("abc", []byte("{}")) // valid, empty core index file
+			cas.insertObject(coreIndexCID, []byte("{}")) // valid, empty core index file
 
 			called := false
 			opts := []SideTreeOption{WithPrefix("test"), WithCAS(cas)}
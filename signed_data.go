@@ -1,5 +1,18 @@
 package sidetree
 
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/gowebpki/jcs"
+	mh "github.com/multiformats/go-multihash"
+)
+
 // The proof files carry the compact-JWS signed data for recover/update/deactivate
 // operations. The Sidetree spec and reference ION serialize this field as
 // "signedData" (lowercase). Without the explicit json tag Go (un)marshals it as
@@ -18,3 +31,165 @@ type SignedRecoverDataOp struct {
 type SignedDeactivateDataOp struct {
 	SignedData string `json:"signedData"`
 }
+
+// Signed-data verification (opt-in, see WithSignatureVerification). This is the
+// reference's per-operation proof check moved to batch-processing time: the
+// compact JWS must carry a protected header of exactly {"alg":"ES256K"}, its
+// payload must contain only the properties the spec defines for the operation
+// type, the signature must verify against the public key carried in that
+// payload, and the operation's reveal value must be the SHA-256 multihash of
+// the canonicalized key. The delta-hash half of the check needs the chunk file,
+// so the verifiers return the signed deltaHash for the processor to compare
+// once deltas are mapped.
+//
+// A failure here invalidates only the one operation, never the batch: the
+// errors below are reported per DID suffix rather than through classifyMalformed.
+
+var (
+	ErrInvalidSignedData   = fmt.Errorf("signed data is not a valid compact JWS")
+	ErrInvalidSignature    = fmt.Errorf("signed data signature does not verify")
+	ErrRevealValueMismatch = fmt.Errorf("reveal value does not match the signing key")
+	ErrDIDSuffixMismatch   = fmt.Errorf("signed data didSuffix does not match the operation")
+	ErrDeltaHashMismatch   = fmt.Errorf("delta does not match the signed deltaHash")
+)
+
+// signedUpdatePayload is the JWS payload of an update operation.
+type signedUpdatePayload struct {
+	UpdateKey json.RawMessage `json:"updateKey"`
+	DeltaHash string          `json:"deltaHash"`
+}
+
+// signedRecoverPayload is the JWS payload of a recover operation.
+type signedRecoverPayload struct {
+	RecoveryCommitment string          `json:"recoveryCommitment"`
+	RecoveryKey        json.RawMessage `json:"recoveryKey"`
+	DeltaHash          string          `json:"deltaHash"`
+	AnchorOrigin       string          `json:"anchorOrigin,omitempty"`
+}
+
+// signedDeactivatePayload is the JWS payload of a deactivate operation.
+type signedDeactivatePayload struct {
+	DIDSuffix   string          `json:"didSuffix"`
+	RecoveryKey json.RawMessage `json:"recoveryKey"`
+}
+
+// verifyUpdateSignedData verifies an update operation's signed data and returns
+// the deltaHash it commits to.
+func verifyUpdateSignedData(revealValue, signedData string) (string, error) {
+	var payload signedUpdatePayload
+	if err := verifySignedData(signedData, &payload, func() json.RawMessage { return payload.UpdateKey }, revealValue); err != nil {
+		return "", err
+	}
+	return payload.DeltaHash, nil
+}
+
+// verifyRecoverSignedData verifies a recover operation's signed data and
+// returns the deltaHash it commits to.
+func verifyRecoverSignedData(revealValue, signedData string) (string, error) {
+	payload, err := verifyRecoverPayload(revealValue, signedData)
+	if err != nil {
+		return "", err
+	}
+	return payload.DeltaHash, nil
+}
+
+// verifyRecoverPayload verifies a recover operation's signed data and returns
+// its payload.
+func verifyRecoverPayload(revealValue, signedData string) (*signedRecoverPayload, error) {
+	var payload signedRecoverPayload
+	if err := verifySignedData(signedData, &payload, func() json.RawMessage { return payload.RecoveryKey }, revealValue); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// verifyDeactivateSignedData verifies a deactivate operation's signed data,
+// including that it was signed for didSuffix.
+func verifyDeactivateSignedData(didSuffix, revealValue, signedData string) error {
+	var payload signedDeactivatePayload
+	if err := verifySignedData(signedData, &payload, func() json.RawMessage { return payload.RecoveryKey }, revealValue); err != nil {
+		return err
+	}
+	if payload.DIDSuffix != didSuffix {
+		return fmt.Errorf("%w: signed %q, operation %q", ErrDIDSuffixMismatch, payload.DIDSuffix, didSuffix)
+	}
+	return nil
+}
+
+// verifySignedData parses signedData as a compact ES256K JWS, strictly decodes
+// its payload into payload, and verifies the signature against the key that
+// signingKey returns from the decoded payload. revealValue must be the SHA-256
+// multihash of that key, canonicalized.
+func verifySignedData(signedData string, payload interface{}, signingKey func() json.RawMessage, revealValue string) error {
+	segments := strings.Split(signedData, ".")
+	if len(segments) != 3 {
+		return fmt.Errorf("%w: not a compact serialization", ErrInvalidSignedData)
+	}
+
+	// Check the protected header ourselves: jose accepts (and partly hides)
+	// header members the reference rejects.
+	rawHeader, err := base64.RawURLEncoding.DecodeString(segments[0])
+	if err != nil {
+		return fmt.Errorf("%w: protected header: %w", ErrInvalidSignedData, err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJSON(DecodeStrict, rawHeader, &header); err != nil {
+		return fmt.Errorf("%w: protected header: %w", ErrInvalidSignedData, err)
+	}
+	if header.Alg != string(jose.ES256K) {
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidSignedData, header.Alg)
+	}
+
+	jws, err := jose.ParseSigned(signedData)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignedData, err)
+	}
+
+	if err := decodeJSON(DecodeStrict, jws.UnsafePayloadWithoutVerification(), payload); err != nil {
+		return fmt.Errorf("%w: payload: %w", ErrInvalidSignedData, err)
+	}
+
+	rawKey := signingKey()
+	canonicalKey, err := jcs.Transform(rawKey)
+	if err != nil {
+		return fmt.Errorf("%w: signing key: %w", ErrInvalidSignedData, err)
+	}
+	if hashed := encodedSHA256Multihash(canonicalKey); hashed != revealValue {
+		return fmt.Errorf("%w: reveal %q, key hashes to %q", ErrRevealValueMismatch, revealValue, hashed)
+	}
+
+	var key jose.JSONWebKey
+	if err := key.UnmarshalJSON(rawKey); err != nil {
+		return fmt.Errorf("%w: signing key: %w", ErrInvalidSignedData, err)
+	}
+	pub, ok := key.Key.(*ecdsa.PublicKey)
+	if !ok || pub.Curve.Params().Name != "secp256k1" {
+		return fmt.Errorf("%w: signing key is not a secp256k1 public key", ErrInvalidSignedData)
+	}
+	if _, err := jws.Verify(pub); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// encodedSHA256Multihash returns the base64url-encoded SHA-256 multihash of
+// data, the encoding Sidetree uses for reveal values and delta hashes.
+func encodedSHA256Multihash(data []byte) string {
+	digest := sha256.Sum256(data)
+	// Encode never fails; its error return is legacy.
+	encoded, _ := mh.Encode(digest[:], SHA256MultihashCode)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// deltaHash returns the encoded SHA-256 multihash of a raw on-wire delta after
+// JCS canonicalization, matching how a writer computes the signed deltaHash.
+func deltaHash(rawDelta []byte) (string, error) {
+	canonical, err := jcs.Transform(rawDelta)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize delta: %w", err)
+	}
+	return encodedSHA256Multihash(canonical), nil
+}
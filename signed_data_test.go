@@ -1,10 +1,98 @@
 package sidetree
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/keys"
+	"github.com/gowebpki/jcs"
 )
 
+// testSigner is a deterministic ES256K key used to produce real compact-JWS
+// signed data for recover, update and deactivate operations.
+type testSigner struct {
+	key *keys.DIDKey
+	// jwk is the public key as it appears in a signed payload.
+	jwk json.RawMessage
+	// reveal is the reveal value for jwk.
+	reveal string
+}
+
+func newTestSigner(t *testing.T, seed string) testSigner {
+	t.Helper()
+	key, err := keys.GenerateES256K([]byte(seed))
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	jwk, err := key.Key().Public().JSON(true)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	canonical, err := jcs.Transform(jwk)
+	if err != nil {
+		t.Fatalf("failed to canonicalize public key: %v", err)
+	}
+	return testSigner{key: key, jwk: jwk, reveal: encodedSHA256Multihash(canonical)}
+}
+
+// sign returns the compact JWS of payload, signed with s.
+func (s testSigner) sign(t *testing.T, payload interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	sig, err := s.key.Key().Sign(b)
+	if err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+	compact, err := sig.Compact()
+	if err != nil {
+		t.Fatalf("failed to serialize signature: %v", err)
+	}
+	return compact
+}
+
+// signUpdate returns update signed data committing to delta.
+func (s testSigner) signUpdate(t *testing.T, delta did.Delta) string {
+	t.Helper()
+	return s.sign(t, map[string]interface{}{"updateKey": s.jwk, "deltaHash": testDeltaHash(t, delta)})
+}
+
+// signRecover returns recover signed data committing to delta.
+func (s testSigner) signRecover(t *testing.T, delta did.Delta) string {
+	t.Helper()
+	return s.sign(t, map[string]interface{}{
+		"recoveryCommitment": "next-recovery-commitment",
+		"recoveryKey":        s.jwk,
+		"deltaHash":          testDeltaHash(t, delta),
+	})
+}
+
+// signDeactivate returns deactivate signed data for didSuffix.
+func (s testSigner) signDeactivate(t *testing.T, didSuffix string) string {
+	t.Helper()
+	return s.sign(t, map[string]interface{}{"didSuffix": didSuffix, "recoveryKey": s.jwk})
+}
+
+// testDeltaHash hashes delta the way it will appear in a marshaled chunk file.
+func testDeltaHash(t *testing.T, delta did.Delta) string {
+	t.Helper()
+	b, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatalf("failed to marshal delta: %v", err)
+	}
+	hash, err := deltaHash(b)
+	if err != nil {
+		t.Fatalf("failed to hash delta: %v", err)
+	}
+	return hash
+}
+
 // TestSignedDataJSONTag pins the spec-correct "signedData" wire key for all three
 // proof-operation types, in both directions. Before the json tags were added the
 // field (un)marshaled as "SignedData", so real ION proof files decoded to an
@@ -39,3 +127,129 @@ func TestSignedDataJSONTag(t *testing.T) {
 		}
 	})
 }
+
+func TestVerifySignedData(t *testing.T) {
+	signer := newTestSigner(t, "signer")
+	other := newTestSigner(t, "other")
+	delta := did.Delta{UpdateCommitment: "update-commitment"}
+	wantHash := testDeltaHash(t, delta)
+
+	// tamper replaces the payload of a valid JWS, keeping its signature.
+	tamper := func(jws string, payload interface{}) string {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("failed to marshal payload: %v", err)
+		}
+		parts := splitCompact(t, jws)
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString(b) + "." + parts[2]
+	}
+	// withHeader swaps the protected header of a valid JWS without re-signing.
+	withHeader := func(jws string, header string) string {
+		parts := splitCompact(t, jws)
+		return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + parts[1] + "." + parts[2]
+	}
+
+	t.Run("update", func(t *testing.T) {
+		tests := map[string]struct {
+			reveal     string
+			signedData string
+			wantErr    error
+		}{
+			"valid": {
+				reveal:     signer.reveal,
+				signedData: signer.signUpdate(t, delta),
+			},
+			"reveal value of another key": {
+				reveal:     other.reveal,
+				signedData: signer.signUpdate(t, delta),
+				wantErr:    ErrRevealValueMismatch,
+			},
+			"signed by another key": {
+				reveal:     signer.reveal,
+				signedData: other.sign(t, map[string]interface{}{"updateKey": signer.jwk, "deltaHash": wantHash}),
+				wantErr:    ErrInvalidSignature,
+			},
+			"tampered payload": {
+				reveal:     signer.reveal,
+				signedData: tamper(signer.signUpdate(t, delta), map[string]interface{}{"updateKey": signer.jwk, "deltaHash": "other"}),
+				wantErr:    ErrInvalidSignature,
+			},
+			"unknown payload property": {
+				reveal:     signer.reveal,
+				signedData: signer.sign(t, map[string]interface{}{"updateKey": signer.jwk, "deltaHash": wantHash, "extra": 1}),
+				wantErr:    ErrInvalidSignedData,
+			},
+			"extra protected header member": {
+				reveal:     signer.reveal,
+				signedData: withHeader(signer.signUpdate(t, delta), `{"alg":"ES256K","kid":"key-1"}`),
+				wantErr:    ErrInvalidSignedData,
+			},
+			"unsupported alg": {
+				reveal:     signer.reveal,
+				signedData: withHeader(signer.signUpdate(t, delta), `{"alg":"ES256"}`),
+				wantErr:    ErrInvalidSignedData,
+			},
+			"not a compact jws": {
+				reveal:     signer.reveal,
+				signedData: "signed-data",
+				wantErr:    ErrInvalidSignedData,
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				got, err := verifyUpdateSignedData(test.reveal, test.signedData)
+				if test.wantErr == nil {
+					if err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+					if got != wantHash {
+						t.Errorf("expected deltaHash %q, got %q", wantHash, got)
+					}
+					return
+				}
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("expected %v, got %v", test.wantErr, err)
+				}
+			})
+		}
+	})
+
+	t.Run("recover", func(t *testing.T) {
+		got, err := verifyRecoverSignedData(signer.reveal, signer.signRecover(t, delta))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != wantHash {
+			t.Errorf("expected deltaHash %q, got %q", wantHash, got)
+		}
+		if _, err := verifyRecoverSignedData(other.reveal, signer.signRecover(t, delta)); !errors.Is(err, ErrRevealValueMismatch) {
+			t.Errorf("expected %v, got %v", ErrRevealValueMismatch, err)
+		}
+		// An update payload is not a recover payload.
+		if _, err := verifyRecoverSignedData(signer.reveal, signer.signUpdate(t, delta)); !errors.Is(err, ErrInvalidSignedData) {
+			t.Errorf("expected %v, got %v", ErrInvalidSignedData, err)
+		}
+	})
+
+	t.Run("deactivate", func(t *testing.T) {
+		if err := verifyDeactivateSignedData("suffix", signer.reveal, signer.signDeactivate(t, "suffix")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := verifyDeactivateSignedData("suffix", signer.reveal, signer.signDeactivate(t, "another-suffix")); !errors.Is(err, ErrDIDSuffixMismatch) {
+			t.Errorf("expected %v, got %v", ErrDIDSuffixMismatch, err)
+		}
+		if err := verifyDeactivateSignedData("suffix", other.reveal, signer.signDeactivate(t, "suffix")); !errors.Is(err, ErrRevealValueMismatch) {
+			t.Errorf("expected %v, got %v", ErrRevealValueMismatch, err)
+		}
+	})
+}
+
+func splitCompact(t *testing.T, jws string) []string {
+	t.Helper()
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		t.Fatalf("not a compact jws: %q", jws)
+	}
+	return parts
+}
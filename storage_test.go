@@ -2,15 +2,37 @@ package sidetree
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"encoding/base32"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+	mh "github.com/multiformats/go-multihash"
 )
 
+// The CIDs the fixtures store core index files under: an anchor string's core
+// index URI must parse as a CID. Each is the testCID of its name.
+const (
+	coreIndexCID      = "bafkreihtnvo6zewjsmzevxw25bgp26mlrdzrw5eagytvgvor7ptyc64ge4"
+	otherCoreIndexCID = "bafkreib2uykrtjphcxb2tbriamn3htatkllvvybr6ktmbbzgk6lypyr2qq"
+	missingCID        = "bafkreih7uy2yhx5gobvypuuexbvq22j2cypeqqfk2lc46225e7b3syq7pu"
+	malformedCID      = "bafkreida5sn3okm5qxqm3u25ibmpvpl4w264tn4iy3x54rccp2n3si2ocm"
+)
+
+// testCID returns the CIDv1 of data stored as a raw block with a SHA-256
+// multihash, the name TestCASStorage.Put stores data under.
+func testCID(data []byte) string {
+	hash, err := mh.Sum(data, mh.SHA2_256, -1)
+	if err != nil {
+		panic(err)
+	}
+	cid := append([]byte{1, 0x55}, hash...)
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(cid))
+}
+
 type Closer struct{}
 
 func (c *Closer) Close() error {
@@ -31,6 +53,10 @@ type TestCASStorage struct {
 	// maxSizes records the maxSizeInBytes the last Get for each id was called
 	// with, so tests can assert the caller passed the correct per-file cap.
 	maxSizes map[string]int
+	// fetched records every id passed to Get, in call order (including ids
+	// that were not found), so tests can assert exactly which files a batch
+	// caused us to download.
+	fetched []string
 }
 
 func (t *TestCASStorage) Start() error {
@@ -44,7 +70,7 @@ func (t *TestCASStorage) Type() CASType {
 func (t *TestCASStorage) Put(data []byte) (string, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	id := fmt.Sprintf("%x", sha256.Sum256(data))
+	id := testCID(data)
 	t.cas[id] = data
 	return id, nil
 }
@@ -56,6 +82,7 @@ func (t *TestCASStorage) Get(id string, maxSizeInBytes int) ([]byte, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.maxSizes[id] = maxSizeInBytes
+	t.fetched = append(t.fetched, id)
 	data, ok := t.cas[id]
 	if !ok {
 		return nil, fmt.Errorf("no data found for id %s", id)
@@ -63,6 +90,13 @@ func (t *TestCASStorage) Get(id string, maxSizeInBytes int) ([]byte, error) {
 	return data, nil
 }
 
+// fetchedURIs returns a copy of the ids requested through Get, in call order.
+func (t *TestCASStorage) fetchedURIs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.fetched...)
+}
+
 func (t *TestCASStorage) insertObject(id string, data []byte) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
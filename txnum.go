@@ -0,0 +1,87 @@
+package sidetree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// ErrInvalidTransactionNumber: a transaction number, or the anchor sequence it
+// is read from, does not parse.
+var ErrInvalidTransactionNumber = fmt.Errorf("invalid transaction number")
+
+// TransactionNumber is the position of an anchor on the ledger: the block
+// height × 2^32 plus the index of the anchoring transaction in its block.
+// Anchors are applied in transaction-number order, and content published late
+// takes effect at the transaction number it was anchored at.
+type TransactionNumber uint64
+
+// NewTransactionNumber returns the transaction number of the transaction at
+// index in the block at height.
+func NewTransactionNumber(height, index uint32) TransactionNumber {
+	return TransactionNumber(uint64(height)<<32 | uint64(index))
+}
+
+// ParseTransactionNumber parses the decimal encoding String returns.
+func ParseTransactionNumber(s string) (TransactionNumber, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q: %w", ErrInvalidTransactionNumber, s, err)
+	}
+	return TransactionNumber(n), nil
+}
+
+// TransactionNumberFromSequence returns the transaction number of the anchor
+// sequence ion-node records an anchor with,
+// "<height>:<block hash>:<tx index>:<tx hash>[:<tx out index>]". Unlike
+// operations.SequenceSignature, which reads a field that is not a number as 0,
+// it rejects a height or index that is not a decimal in range.
+func TransactionNumberFromSequence(sequence operations.SequenceSignature) (TransactionNumber, error) {
+	parts := strings.Split(string(sequence), ":")
+	if len(parts) != 4 && len(parts) != 5 {
+		return 0, fmt.Errorf("%w: sequence %q does not have 4 or 5 fields", ErrInvalidTransactionNumber, sequence)
+	}
+	height, err := strconv.ParseUint(parts[operations.HEIGHT_INDEX], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: sequence %q height: %w", ErrInvalidTransactionNumber, sequence, err)
+	}
+	index, err := strconv.ParseUint(parts[operations.TXINDEX_INDEX], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: sequence %q transaction index: %w", ErrInvalidTransactionNumber, sequence, err)
+	}
+	return NewTransactionNumber(uint32(height), uint32(index)), nil
+}
+
+// Height returns the block height of n.
+func (n TransactionNumber) Height() uint32 {
+	return uint32(n >> 32)
+}
+
+// Index returns the index of n's transaction in its block.
+func (n TransactionNumber) Index() uint32 {
+	return uint32(n)
+}
+
+// Sequence returns the anchor sequence of n, given the hashes of its block
+// and transaction, which n does not hold.
+func (n TransactionNumber) Sequence(blockHash, txHash string) operations.SequenceSignature {
+	return operations.NewSequence(int(n.Height()), blockHash, int(n.Index()), txHash)
+}
+
+// Compare returns -1, 0 or +1 as n is before, at or after other.
+func (n TransactionNumber) Compare(other TransactionNumber) int {
+	switch {
+	case n < other:
+		return -1
+	case n > other:
+		return 1
+	}
+	return 0
+}
+
+// String returns n in decimal.
+func (n TransactionNumber) String() string {
+	return strconv.FormatUint(uint64(n), 10)
+}
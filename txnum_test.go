@@ -0,0 +1,91 @@
+package sidetree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestTransactionNumber(t *testing.T) {
+	n := NewTransactionNumber(700000, 12)
+	if n != 700000<<32+12 {
+		t.Errorf("expected height × 2^32 + index, got %d", n)
+	}
+	if n.Height() != 700000 || n.Index() != 12 {
+		t.Errorf("expected height 700000 and index 12, got %d and %d", n.Height(), n.Index())
+	}
+
+	parsed, err := ParseTransactionNumber(n.String())
+	if err != nil || parsed != n {
+		t.Errorf("expected %s to parse back, got %d, %v", n, parsed, err)
+	}
+	if _, err := ParseTransactionNumber("-1"); !errors.Is(err, ErrInvalidTransactionNumber) {
+		t.Errorf("expected %v, got %v", ErrInvalidTransactionNumber, err)
+	}
+
+	later := NewTransactionNumber(700000, 13)
+	next := NewTransactionNumber(700001, 0)
+	if n.Compare(later) != -1 || later.Compare(next) != -1 || next.Compare(n) != 1 || n.Compare(n) != 0 {
+		t.Errorf("expected %s < %s < %s", n, later, next)
+	}
+}
+
+func TestTransactionNumberFromSequence(t *testing.T) {
+	tests := map[string]struct {
+		sequence operations.SequenceSignature
+		want     TransactionNumber
+		wantErr  error
+	}{
+		"four fields":        {sequence: "1234:abcd:56:efgh", want: NewTransactionNumber(1234, 56)},
+		"with output index":  {sequence: "1234:abcd:56:efgh:7", want: NewTransactionNumber(1234, 56)},
+		"largest":            {sequence: "4294967295:abcd:4294967295:efgh", want: 1<<64 - 1},
+		"bare number":        {sequence: "42", wantErr: ErrInvalidTransactionNumber},
+		"too many fields":    {sequence: "1:a:2:b:3:c", wantErr: ErrInvalidTransactionNumber},
+		"height not numeric": {sequence: "x:abcd:56:efgh", wantErr: ErrInvalidTransactionNumber},
+		"negative index":     {sequence: "1234:abcd:-1:efgh", wantErr: ErrInvalidTransactionNumber},
+		"height overflow":    {sequence: "4294967296:abcd:0:efgh", wantErr: ErrInvalidTransactionNumber},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := TransactionNumberFromSequence(test.sequence)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+			if got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+
+	n := NewTransactionNumber(1234, 56)
+	if got := n.Sequence("abcd", "efgh"); got != "1234:abcd:56:efgh" {
+		t.Errorf("expected 1234:abcd:56:efgh, got %s", got)
+	}
+}
+
+func TestProcessedOperationsTransactionNumber(t *testing.T) {
+	tests := map[operations.SequenceSignature]struct {
+		want    TransactionNumber
+		wantErr error
+	}{
+		"1234:abcd:56:efgh:7": {want: NewTransactionNumber(1234, 56)},
+		"42":                  {wantErr: ErrInvalidTransactionNumber},
+		"":                    {wantErr: ErrInvalidTransactionNumber},
+	}
+
+	for sequence, test := range tests {
+		p, err := Processor(operations.Anchor{Anchor: "1." + missingCID, Sequence: sequence}, WithCAS(NewTestCAS()), WithPrefix("test"))
+		if err != nil {
+			t.Fatalf("expected no error creating processor, got %v", err)
+		}
+		got := p.Process()
+		if !errors.Is(got.TransactionNumberError, test.wantErr) {
+			t.Errorf("sequence %q: expected %v, got %v", sequence, test.wantErr, got.TransactionNumberError)
+		}
+		if test.wantErr == nil && got.TransactionNumber != test.want {
+			t.Errorf("sequence %q: expected transaction number %s, got %s", sequence, test.want, got.TransactionNumber)
+		}
+	}
+}
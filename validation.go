@@ -1,9 +1,11 @@
 package sidetree
 
 import (
+	"encoding/base64"
 	"fmt"
 
 	"github.com/gowebpki/jcs"
+	mh "github.com/multiformats/go-multihash"
 )
 
 // Per-field caps enforced at file-parse time, matching what the Sidetree v1
@@ -20,6 +22,62 @@ import (
 //   - reveal values are not length-checked (the reference validates them as
 //     supported-algorithm multihashes; ion-sdk-go's did.CheckReveal enforces the
 //     SHA-256 algorithm — hashAlgorithmsInMultihashCode=[18] — at apply time).
+//     That multihash check can be moved to parse time with
+//     WithMultihashValidation; it is off unless configured.
+
+// MultihashCheck selects which operation-reference fields are validated as
+// base64url-encoded SHA-256 multihashes when the index files are parsed. The
+// zero value validates nothing, leaving the check to ion-sdk-go at apply time.
+type MultihashCheck int
+
+const (
+	// CheckDIDSuffixes validates the didSuffix of every recover, deactivate and
+	// update entry. Create suffixes are computed from the suffix data and are
+	// always well formed.
+	CheckDIDSuffixes MultihashCheck = 1 << iota
+
+	// CheckRevealValues validates the revealValue of every recover, deactivate
+	// and update entry.
+	CheckRevealValues
+
+	// CheckReferenceMultihashes is what the reference implementation checks
+	// when it parses the core and provisional index files: both fields.
+	CheckReferenceMultihashes = CheckDIDSuffixes | CheckRevealValues
+)
+
+// checkOperationReference applies the configured multihash checks to one
+// recover/deactivate/update index entry. name identifies the entry type for
+// the error message.
+func checkOperationReference(checks MultihashCheck, name string, op Operation) error {
+	if checks&CheckDIDSuffixes != 0 {
+		if err := checkEncodedMultihash(op.DIDSuffix); err != nil {
+			return fmt.Errorf("%w: %s didSuffix %q: %w", ErrInvalidDIDSuffix, name, op.DIDSuffix, err)
+		}
+	}
+	if checks&CheckRevealValues != 0 {
+		if err := checkEncodedMultihash(op.RevealValue); err != nil {
+			return fmt.Errorf("%w: %s revealValue for %q: %w", ErrInvalidRevealValue, name, op.DIDSuffix, err)
+		}
+	}
+	return nil
+}
+
+// checkEncodedMultihash verifies that value is unpadded base64url and decodes
+// to a well-formed multihash using SHA256MultihashCode.
+func checkEncodedMultihash(value string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return fmt.Errorf("not base64url: %w", err)
+	}
+	decoded, err := mh.Decode(raw)
+	if err != nil {
+		return fmt.Errorf("not a multihash: %w", err)
+	}
+	if decoded.Code != SHA256MultihashCode {
+		return fmt.Errorf("unsupported multihash code %#x", decoded.Code)
+	}
+	return nil
+}
 
 // checkCASURI rejects an embedded CAS/IPFS URI longer than MaxCASURILength.
 // Empty URIs (optional fields) pass.
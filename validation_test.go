@@ -0,0 +1,110 @@
+package sidetree
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// testMultihash returns the base64url-encoded SHA-256 multihash of s, the
+// encoding Sidetree uses for DID suffixes and reveal values.
+func testMultihash(t *testing.T, s string) string {
+	t.Helper()
+	digest := sha256.Sum256([]byte(s))
+	encoded, err := mh.Encode(digest[:], mh.SHA2_256)
+	if err != nil {
+		t.Fatalf("failed to encode multihash: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func TestCheckEncodedMultihash(t *testing.T) {
+	sha512Digest := sha512.Sum512([]byte("value"))
+	sha512Multihash, err := mh.Encode(sha512Digest[:], mh.SHA2_512)
+	if err != nil {
+		t.Fatalf("failed to encode multihash: %v", err)
+	}
+	truncated, err := base64.RawURLEncoding.DecodeString(testMultihash(t, "value"))
+	if err != nil {
+		t.Fatalf("failed to decode multihash: %v", err)
+	}
+
+	tests := map[string]struct {
+		value   string
+		wantErr bool
+	}{
+		"sha-256 multihash":      {value: testMultihash(t, "value")},
+		"empty":                  {value: "", wantErr: true},
+		"not base64url":          {value: "did:abc:123", wantErr: true},
+		"padded base64url":       {value: testMultihash(t, "value") + "=", wantErr: true},
+		"not a multihash":        {value: base64.RawURLEncoding.EncodeToString([]byte("reveal-value")), wantErr: true},
+		"truncated digest":       {value: base64.RawURLEncoding.EncodeToString(truncated[:len(truncated)-1]), wantErr: true},
+		"unsupported hash code":  {value: base64.RawURLEncoding.EncodeToString(sha512Multihash), wantErr: true},
+		"plain base64url string": {value: "EiAcia", wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkEncodedMultihash(test.value)
+			if test.wantErr != (err != nil) {
+				t.Errorf("checkEncodedMultihash(%q) = %v, want error %t", test.value, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOperationReference(t *testing.T) {
+	valid := testMultihash(t, "value")
+
+	tests := map[string]struct {
+		checks  MultihashCheck
+		op      Operation
+		wantErr error
+	}{
+		"no checks accept anything": {
+			checks: 0,
+			op:     Operation{DIDSuffix: "did:abc:123", RevealValue: "reveal-value"},
+		},
+		"suffix check rejects bad suffix": {
+			checks:  CheckDIDSuffixes,
+			op:      Operation{DIDSuffix: "did:abc:123", RevealValue: valid},
+			wantErr: ErrInvalidDIDSuffix,
+		},
+		"suffix check ignores reveal value": {
+			checks: CheckDIDSuffixes,
+			op:     Operation{DIDSuffix: valid, RevealValue: "reveal-value"},
+		},
+		"reveal check rejects bad reveal value": {
+			checks:  CheckRevealValues,
+			op:      Operation{DIDSuffix: valid, RevealValue: "reveal-value"},
+			wantErr: ErrInvalidRevealValue,
+		},
+		"reveal check ignores suffix": {
+			checks: CheckRevealValues,
+			op:     Operation{DIDSuffix: "did:abc:123", RevealValue: valid},
+		},
+		"reference checks accept valid entry": {
+			checks: CheckReferenceMultihashes,
+			op:     Operation{DIDSuffix: valid, RevealValue: valid},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkOperationReference(test.checks, "recover", test.op)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+// Writer-side errors. A batch is checked against the same protocol limits the
+// reader enforces, so that Write never anchors a batch a compliant node would
+// reject; the reader's sentinels are reused where the rule is the same.
+var (
+	ErrEmptyBatch           = fmt.Errorf("batch has no operations")
+	ErrUnsupportedOperation = fmt.Errorf("unsupported operation type")
+)
+
+type BatchOption func(b *Batch)
+
+// WithBatchWriterLockID sets the writerLockId of the core index file, which a
+// batch of more than MaxNumberOfOperationsForNoValueTimeLock operations needs.
+func WithBatchWriterLockID(writerLockID string) BatchOption {
+	return func(b *Batch) {
+		b.writerLockID = writerLockID
+	}
+}
+
+// Batch is a set of operations to be written as the Sidetree files of a
+// single anchor.
+type Batch struct {
+	writerLockID string
+
+	creates     []*operations.Create
+	recovers    []*operations.Recover
+	updates     []*operations.Update
+	deactivates []*operations.Deactivate
+}
+
+// NewBatch groups ops, which must be *operations.Create, *operations.Recover,
+// *operations.Update or *operations.Deactivate values, into a batch. It
+// rejects a batch a reader would reject before any file is written: more than
+// one operation for a DID suffix, too many operations, an oversized delta or
+// writerLockId.
+func NewBatch(ops []interface{}, opts ...BatchOption) (*Batch, error) {
+	b := &Batch{}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if len(ops) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if err := checkWriterLockID(b.writerLockID); err != nil {
+		return nil, err
+	}
+
+	suffixes := map[string]struct{}{}
+	addSuffix := func(suffix string) error {
+		if _, ok := suffixes[suffix]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateOperation, suffix)
+		}
+		suffixes[suffix] = struct{}{}
+		return nil
+	}
+
+	for i, op := range ops {
+		opType, suffix, delta, err := describeOperation(op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		switch opType {
+		case OperationCreate:
+			b.creates = append(b.creates, op.(*operations.Create))
+		case OperationRecover:
+			b.recovers = append(b.recovers, op.(*operations.Recover))
+		case OperationUpdate:
+			b.updates = append(b.updates, op.(*operations.Update))
+		case OperationDeactivate:
+			b.deactivates = append(b.deactivates, op.(*operations.Deactivate))
+		}
+
+		if err := addSuffix(suffix); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if err := checkOperationDelta(delta); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	n := b.OperationCount()
+	if n > MaxOperationsPerBatch {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTooManyOperations, n, MaxOperationsPerBatch)
+	}
+	if n > MaxNumberOfOperationsForNoValueTimeLock && b.writerLockID == "" {
+		return nil, fmt.Errorf("%w: %d > %d", ErrOperationLimitExceeded, n, MaxNumberOfOperationsForNoValueTimeLock)
+	}
+
+	return b, nil
+}
+
+// describeOperation returns the type, DID suffix and delta of op, which must
+// be one of the operation types NewBatch accepts. A deactivate has no delta.
+func describeOperation(op interface{}) (OperationType, string, *did.Delta, error) {
+	switch op := op.(type) {
+	case *operations.Create:
+		suffix, err := op.SuffixData.URI()
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to compute DID suffix: %w", err)
+		}
+		return OperationCreate, suffix, &op.Delta, nil
+	case *operations.Recover:
+		return OperationRecover, op.DIDSuffix, &op.Delta, nil
+	case *operations.Update:
+		return OperationUpdate, op.DIDSuffix, &op.Delta, nil
+	case *operations.Deactivate:
+		return OperationDeactivate, op.DIDSuffix, nil, nil
+	default:
+		return "", "", nil, fmt.Errorf("%w: %T", ErrUnsupportedOperation, op)
+	}
+}
+
+// checkOperationDelta applies the delta size cap to delta, if there is one.
+func checkOperationDelta(delta *did.Delta) error {
+	if delta == nil {
+		return nil
+	}
+	raw, err := json.Marshal(delta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delta: %w", err)
+	}
+	return checkDeltaSize(raw)
+}
+
+// OperationCount returns the number of operations in the batch, the count
+// the anchor string declares.
+func (b *Batch) OperationCount() int {
+	return len(b.creates) + len(b.recovers) + len(b.updates) + len(b.deactivates)
+}
+
+// ops returns the operations of the batch, as NewBatch was given them.
+func (b *Batch) ops() []interface{} {
+	ops := make([]interface{}, 0, b.OperationCount())
+	for _, op := range b.creates {
+		ops = append(ops, op)
+	}
+	for _, op := range b.recovers {
+		ops = append(ops, op)
+	}
+	for _, op := range b.updates {
+		ops = append(ops, op)
+	}
+	for _, op := range b.deactivates {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Write stores the files of the batch in cas, each file before the files that
+// reference it, and returns the anchor string of the batch. Every file is
+// encoded canonically (see CoreIndexFile.Encode) and checked against its size
+// cap before it is stored; the check is on the uncompressed encoding, so it
+// holds whatever compression the CAS applies.
+func (b *Batch) Write(cas CAS) (operations.AnchorString, error) {
+	var provisionalIndexURI string
+	if len(b.creates)+len(b.recovers)+len(b.updates) > 0 {
+		chunkURI, err := b.put(cas, FileChunk, MaxChunkFileSizeInBytes, b.chunkFile())
+		if err != nil {
+			return "", err
+		}
+
+		var provisionalProofURI string
+		if len(b.updates) > 0 {
+			provisionalProofURI, err = b.put(cas, FileProvisionalProof, MaxProofFileSizeInBytes, b.provisionalProofFile())
+			if err != nil {
+				return "", err
+			}
+		}
+
+		provisionalIndexURI, err = b.put(cas, FileProvisionalIndex, MaxProvisionalIndexFileSizeInBytes, b.provisionalIndexFile(chunkURI, provisionalProofURI))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var coreProofURI string
+	if len(b.recovers)+len(b.deactivates) > 0 {
+		var err error
+		coreProofURI, err = b.put(cas, FileCoreProof, MaxProofFileSizeInBytes, b.coreProofFile())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	coreIndexURI, err := b.put(cas, FileCoreIndex, MaxCoreIndexFileSizeInBytes, b.coreIndexFile(provisionalIndexURI, coreProofURI))
+	if err != nil {
+		return "", err
+	}
+
+	return AnchorString{Operations: b.OperationCount(), CoreIndexFileURI: coreIndexURI}.Format()
+}
+
+// fileEncoder is implemented by every Sidetree file type.
+type fileEncoder interface {
+	Encode() ([]byte, error)
+}
+
+// put encodes f canonically, checks it against maxSizeInBytes and stores it.
+func (b *Batch) put(cas CAS, file FileType, maxSizeInBytes int, f fileEncoder) (string, error) {
+	data, err := f.Encode()
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxSizeInBytes {
+		return "", fmt.Errorf("%w: %s is %d bytes (limit %d)", ErrFileTooLarge, file.description(), len(data), maxSizeInBytes)
+	}
+
+	uri, err := cas.Put(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to put %s: %w", file.description(), err)
+	}
+	if err := checkCASURI(file.description()+" uri", uri); err != nil {
+		return "", err
+	}
+	return uri, nil
+}
+
+func (b *Batch) coreIndexFile(provisionalIndexURI, coreProofURI string) *CoreIndexFile {
+	f := &CoreIndexFile{
+		WriterLockId:        b.writerLockID,
+		ProvisionalIndexURI: provisionalIndexURI,
+		CoreProofURI:        coreProofURI,
+	}
+	for _, op := range b.creates {
+		f.Operations.Create = append(f.Operations.Create, CreateOperation{SuffixData: op.SuffixData})
+	}
+	for _, op := range b.recovers {
+		f.Operations.Recover = append(f.Operations.Recover, Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue})
+	}
+	for _, op := range b.deactivates {
+		f.Operations.Deactivate = append(f.Operations.Deactivate, Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue})
+	}
+	return f
+}
+
+func (b *Batch) coreProofFile() *CoreProofFile {
+	f := &CoreProofFile{}
+	for _, op := range b.recovers {
+		f.Operations.Recover = append(f.Operations.Recover, SignedRecoverDataOp{SignedData: op.SignedData})
+	}
+	for _, op := range b.deactivates {
+		f.Operations.Deactivate = append(f.Operations.Deactivate, SignedDeactivateDataOp{SignedData: op.SignedData})
+	}
+	return f
+}
+
+func (b *Batch) provisionalIndexFile(chunkURI, provisionalProofURI string) *ProvisionalIndexFile {
+	f := &ProvisionalIndexFile{
+		ProvisionalProofURI: provisionalProofURI,
+		Chunks:              []ProvChunk{{ChunkFileURI: chunkURI}},
+	}
+	for _, op := range b.updates {
+		f.Operations.Update = append(f.Operations.Update, Operation{DIDSuffix: op.DIDSuffix, RevealValue: op.RevealValue})
+	}
+	return f
+}
+
+func (b *Batch) provisionalProofFile() *ProvisionalProofFile {
+	f := &ProvisionalProofFile{}
+	for _, op := range b.updates {
+		f.Operations.Update = append(f.Operations.Update, SignedUpdateDataOp{SignedData: op.SignedData})
+	}
+	return f
+}
+
+// chunkFile holds the deltas in operation delta mapping array order: creates,
+// then recovers, then updates.
+func (b *Batch) chunkFile() *ChunkFile {
+	f := &ChunkFile{}
+	for _, op := range b.creates {
+		f.Deltas = append(f.Deltas, op.Delta)
+	}
+	for _, op := range b.recovers {
+		f.Deltas = append(f.Deltas, op.Delta)
+	}
+	for _, op := range b.updates {
+		f.Deltas = append(f.Deltas, op.Delta)
+	}
+	return f
+}
@@ -0,0 +1,199 @@
+package sidetree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/13x-tech/ion-sdk-go/pkg/did"
+	"github.com/13x-tech/ion-sdk-go/pkg/operations"
+)
+
+func TestBatchWriteRoundTrip(t *testing.T) {
+	recoverKey := newTestSigner(t, "recover")
+	deactivateKey := newTestSigner(t, "deactivate")
+	updateKey := newTestSigner(t, "update")
+
+	createDelta := did.Delta{UpdateCommitment: "create-commitment"}
+	recoverDelta := did.Delta{UpdateCommitment: "recover-commitment"}
+	updateDelta := did.Delta{UpdateCommitment: "update-commitment"}
+
+	create := operations.CreateOperation(did.SuffixData{DeltaHash: testDeltaHash(t, createDelta), RecoveryCommitment: "xyz789"})
+	create.SetDelta(createDelta)
+	createSuffix, err := create.SuffixData.URI()
+	if err != nil {
+		t.Fatalf("failed to compute create suffix: %v", err)
+	}
+	recover := operations.RecoverOperation("recover-did", recoverKey.reveal, recoverKey.signRecover(t, recoverDelta))
+	recover.SetDelta(recoverDelta)
+	update := operations.UpdateOperation("update-did", updateKey.reveal, updateKey.signUpdate(t, updateDelta))
+	update.SetDelta(updateDelta)
+	deactivate := operations.DeactivateOperation("deactivate-did", deactivateKey.reveal, deactivateKey.signDeactivate(t, "deactivate-did"))
+
+	tests := map[string]struct {
+		ops       []interface{}
+		wantFiles int
+		want      map[string]OperationType
+	}{
+		"all operation types": {
+			ops:       []interface{}{update, deactivate, create, recover},
+			wantFiles: 5,
+			want: map[string]OperationType{
+				createSuffix:     OperationCreate,
+				"recover-did":    OperationRecover,
+				"update-did":     OperationUpdate,
+				"deactivate-did": OperationDeactivate,
+			},
+		},
+		"deactivate only": {
+			ops:       []interface{}{deactivate},
+			wantFiles: 2,
+			want:      map[string]OperationType{"deactivate-did": OperationDeactivate},
+		},
+		"update only": {
+			ops:       []interface{}{update},
+			wantFiles: 4,
+			want:      map[string]OperationType{"update-did": OperationUpdate},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := NewBatch(test.ops)
+			if err != nil {
+				t.Fatalf("expected no error building batch, got %v", err)
+			}
+			cas := NewTestCAS()
+			anchor, err := b.Write(cas)
+			if err != nil {
+				t.Fatalf("expected no error writing batch, got %v", err)
+			}
+			if want := fmt.Sprintf("%d.", len(test.ops)); !strings.HasPrefix(string(anchor), want) {
+				t.Errorf("expected anchor to declare %d operations, got %s", len(test.ops), anchor)
+			}
+			if len(cas.cas) != test.wantFiles {
+				t.Errorf("expected %d files, got %d", test.wantFiles, len(cas.cas))
+			}
+
+			p, err := Processor(operations.Anchor{Anchor: anchor}, WithCAS(cas), WithPrefix("test"), WithSignatureVerification(true))
+			if err != nil {
+				t.Fatalf("expected no error creating processor, got %v", err)
+			}
+			got := p.Process()
+			if got.Error != nil {
+				t.Fatalf("expected written batch to process, got %v", got.Error)
+			}
+			if len(got.Results) != len(test.want) {
+				t.Errorf("expected %d results, got %v", len(test.want), got.Results)
+			}
+			for suffix, opType := range test.want {
+				result, ok := got.Results[suffix]
+				if !ok {
+					t.Errorf("expected a result for %s", suffix)
+					continue
+				}
+				if result.Type != opType || !result.Valid() {
+					t.Errorf("expected valid %s for %s, got %+v", opType, suffix, result)
+				}
+			}
+		})
+	}
+}
+
+func TestNewBatchRejects(t *testing.T) {
+	deactivate := func(suffix string) *operations.Deactivate {
+		return operations.DeactivateOperation(suffix, "reveal", "signed-data")
+	}
+	deactivates := func(n int) []interface{} {
+		ops := make([]interface{}, n)
+		for i := range ops {
+			ops[i] = deactivate(fmt.Sprintf("did-%d", i))
+		}
+		return ops
+	}
+	bigDelta := operations.UpdateOperation("update-did", "reveal", "signed-data")
+	bigDelta.SetDelta(did.Delta{UpdateCommitment: strings.Repeat("a", MaxDeltaSizeInBytes)})
+
+	tests := map[string]struct {
+		ops     []interface{}
+		opts    []BatchOption
+		wantErr error
+	}{
+		"empty": {
+			wantErr: ErrEmptyBatch,
+		},
+		"unsupported operation": {
+			ops:     []interface{}{operations.Deactivate{}},
+			wantErr: ErrUnsupportedOperation,
+		},
+		"duplicate suffix": {
+			ops:     []interface{}{deactivate("did"), operations.UpdateOperation("did", "reveal", "signed-data")},
+			wantErr: ErrDuplicateOperation,
+		},
+		"delta too large": {
+			ops:     []interface{}{bigDelta},
+			wantErr: ErrDeltaTooLarge,
+		},
+		"writer lock id too long": {
+			ops:     []interface{}{deactivate("did")},
+			opts:    []BatchOption{WithBatchWriterLockID(strings.Repeat("a", MaxWriterLockIDInBytes+1))},
+			wantErr: ErrWriterLockIDTooLong,
+		},
+		"over free limit without writer lock": {
+			ops:     deactivates(MaxNumberOfOperationsForNoValueTimeLock + 1),
+			wantErr: ErrOperationLimitExceeded,
+		},
+		"over free limit with writer lock": {
+			ops:  deactivates(MaxNumberOfOperationsForNoValueTimeLock + 1),
+			opts: []BatchOption{WithBatchWriterLockID("lock")},
+		},
+		"over batch limit": {
+			ops:     deactivates(MaxOperationsPerBatch + 1),
+			opts:    []BatchOption{WithBatchWriterLockID("lock")},
+			wantErr: ErrTooManyOperations,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewBatch(test.ops, test.opts...)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBatchWriteOmitsEmptyProperties(t *testing.T) {
+	b, err := NewBatch([]interface{}{operations.DeactivateOperation("did", "reveal", "signed-data")})
+	if err != nil {
+		t.Fatalf("expected no error building batch, got %v", err)
+	}
+	cas := NewTestCAS()
+	anchor, err := b.Write(cas)
+	if err != nil {
+		t.Fatalf("expected no error writing batch, got %v", err)
+	}
+
+	_, uri, _ := strings.Cut(string(anchor), ".")
+	var coreIndex map[string]json.RawMessage
+	if err := json.Unmarshal(cas.cas[uri], &coreIndex); err != nil {
+		t.Fatalf("failed to decode core index file: %v", err)
+	}
+	for _, key := range []string{"writerLockId", "provisionalIndexFileUri"} {
+		if _, ok := coreIndex[key]; ok {
+			t.Errorf("expected %s to be omitted, got %s", key, cas.cas[uri])
+		}
+	}
+	if _, ok := coreIndex["coreProofFileUri"]; !ok {
+		t.Errorf("expected coreProofFileUri, got %s", cas.cas[uri])
+	}
+}